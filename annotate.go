@@ -0,0 +1,452 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"golang.org/x/image/vector"
+)
+
+// annotationTool identifica a ferramenta ativa no modo de anotação.
+type annotationTool int
+
+const (
+	toolNone annotationTool = iota
+	toolRect
+	toolEllipse
+	toolArrow
+	toolLine
+	toolPen
+	toolPixelate
+	toolBlur
+	toolBlackout
+	toolText
+)
+
+func (t annotationTool) String() string {
+	switch t {
+	case toolRect:
+		return "retângulo"
+	case toolEllipse:
+		return "elipse"
+	case toolArrow:
+		return "seta"
+	case toolLine:
+		return "linha"
+	case toolPen:
+		return "caneta"
+	case toolPixelate:
+		return "pixelizar"
+	case toolBlur:
+		return "desfoque"
+	case toolBlackout:
+		return "tarja preta"
+	case toolText:
+		return "texto"
+	}
+	return "nenhuma"
+}
+
+// annotation é um objeto vetorial em coordenadas de rawBG. Formas simples
+// usam dois pontos (início e fim do arrasto); a caneta guarda o traço todo e
+// o texto, só o canto superior esquerdo da caixa.
+type annotation struct {
+	Tool   annotationTool
+	Points []image.Point
+	Color  color.RGBA
+	Width  int
+
+	// texto
+	Text string
+	Size int  // em pixels
+	Pill bool // fundo arredondado
+}
+
+// annotationColors é a paleta alternada com a tecla C.
+var annotationColors = []color.RGBA{
+	{R: 230, G: 40, B: 40, A: 255},
+	{R: 250, G: 200, B: 0, A: 255},
+	{R: 40, G: 180, B: 70, A: 255},
+	{R: 40, G: 120, B: 240, A: 255},
+	{R: 255, G: 255, B: 255, A: 255},
+	{R: 0, G: 0, B: 0, A: 255},
+}
+
+const (
+	defaultStrokeWidth = 4
+	maxStrokeWidth     = 32
+)
+
+// valid descarta cliques sem arrasto.
+func (an annotation) valid() bool {
+	if an.Tool == toolText {
+		return an.Text != "" && len(an.Points) > 0
+	}
+	if len(an.Points) < 2 {
+		return false
+	}
+	r := pointsBounds(an.Points)
+	if an.Tool.isRedaction() {
+		return r.Dx() >= 2 && r.Dy() >= 2
+	}
+	return r.Dx() >= 2 || r.Dy() >= 2
+}
+
+// bounds é a área afetada pela anotação (inclui espessura e ponta da seta).
+func (an annotation) bounds() image.Rectangle {
+	if an.Tool.isRedaction() {
+		return an.region()
+	}
+	if an.Tool == toolText {
+		box, _, _ := textLayout(an)
+		return box
+	}
+	pad := an.Width + 1
+	if an.Tool == toolArrow {
+		pad += int(arrowHeadLength(float64(an.Width)))
+	}
+	r := pointsBounds(an.Points)
+	return image.Rect(r.Min.X-pad, r.Min.Y-pad, r.Max.X+pad+1, r.Max.Y+pad+1)
+}
+
+func pointsBounds(pts []image.Point) image.Rectangle {
+	if len(pts) == 0 {
+		return image.Rectangle{}
+	}
+	r := image.Rectangle{Min: pts[0], Max: pts[0]}
+	for _, p := range pts[1:] {
+		r.Min.X = min(r.Min.X, p.X)
+		r.Min.Y = min(r.Min.Y, p.Y)
+		r.Max.X = max(r.Max.X, p.X)
+		r.Max.Y = max(r.Max.Y, p.Y)
+	}
+	return r
+}
+
+// drawAnnotations rasteriza as anotações em dst, que já deve conter os pixels
+// de rawBG. origin é a posição de rawBG que corresponde a (0,0) em dst (o
+// canto da seleção, na exportação). As tarjas são aplicadas antes das formas,
+// para que setas e textos continuem visíveis sobre elas.
+func drawAnnotations(dst *image.RGBA, annos []annotation, origin image.Point) {
+	for _, an := range annos {
+		if an.Tool.isRedaction() {
+			redact(dst, an.Tool, an.region().Sub(origin))
+		}
+	}
+	for _, an := range annos {
+		if an.Tool.isRedaction() {
+			continue
+		}
+		if an.Tool == toolText {
+			drawText(dst, an, origin)
+			continue
+		}
+		mask := annotationMask(an, origin, dst.Bounds())
+		if mask == nil {
+			continue
+		}
+		draw.DrawMask(dst, mask.Rect, image.NewUniform(an.Color), image.Point{}, mask, mask.Rect.Min, draw.Over)
+	}
+}
+
+// annotationMask gera a cobertura (com antialiasing) da anotação recortada
+// em clip. Retorna nil se nada ficar visível.
+func annotationMask(an annotation, origin image.Point, clip image.Rectangle) *image.Alpha {
+	area := an.bounds().Sub(origin).Intersect(clip)
+	if area.Empty() || len(an.Points) == 0 {
+		return nil
+	}
+	mask := image.NewAlpha(area)
+	pts := make([]vec2, len(an.Points))
+	for i, p := range an.Points {
+		q := p.Sub(origin)
+		pts[i] = vec2{float64(q.X) + 0.5, float64(q.Y) + 0.5}
+	}
+	w := float64(max(an.Width, 1))
+
+	switch an.Tool {
+	case toolRect:
+		r := pointsBounds(an.Points).Sub(origin)
+		x0, y0 := float64(r.Min.X)+0.5, float64(r.Min.Y)+0.5
+		x1, y1 := float64(r.Max.X)+0.5, float64(r.Max.Y)+0.5
+		h := w / 2
+		outer := []vec2{{x0 - h, y0 - h}, {x1 + h, y0 - h}, {x1 + h, y1 + h}, {x0 - h, y1 + h}}
+		paths := [][]vec2{outer}
+		if x1-x0 > w && y1-y0 > w {
+			// contorno interno no sentido oposto vira um furo
+			paths = append(paths, []vec2{{x0 + h, y0 + h}, {x0 + h, y1 - h}, {x1 - h, y1 - h}, {x1 - h, y0 + h}})
+		}
+		fillPaths(mask, paths)
+	case toolEllipse:
+		r := pointsBounds(an.Points).Sub(origin)
+		c := vec2{float64(r.Min.X+r.Max.X)/2 + 0.5, float64(r.Min.Y+r.Max.Y)/2 + 0.5}
+		rx, ry := float64(r.Dx())/2, float64(r.Dy())/2
+		paths := [][]vec2{ellipsePath(c, rx+w/2, ry+w/2, false)}
+		if rx > w/2 && ry > w/2 {
+			paths = append(paths, ellipsePath(c, rx-w/2, ry-w/2, true))
+		}
+		fillPaths(mask, paths)
+	case toolArrow:
+		a, b := pts[0], pts[len(pts)-1]
+		d := b.sub(a)
+		length := d.len()
+		if length == 0 {
+			return nil
+		}
+		u := d.scale(1 / length)
+		head := math.Min(arrowHeadLength(w), length)
+		base := b.sub(u.scale(head))
+		strokeSegment(mask, a, base.add(u.scale(w/2)), w)
+		fillDisc(mask, a, w/2)
+		n := u.perp().scale(head * 0.55)
+		fillPaths(mask, [][]vec2{{b, base.add(n), base.sub(n)}})
+	case toolLine:
+		a, b := pts[0], pts[len(pts)-1]
+		strokeSegment(mask, a, b, w)
+		fillDisc(mask, a, w/2)
+		fillDisc(mask, b, w/2)
+	case toolPen:
+		fillDisc(mask, pts[0], w/2)
+		for i := 1; i < len(pts); i++ {
+			strokeSegment(mask, pts[i-1], pts[i], w)
+			fillDisc(mask, pts[i], w/2)
+		}
+	default:
+		return nil
+	}
+	return mask
+}
+
+func arrowHeadLength(w float64) float64 {
+	return math.Max(12, 4*w)
+}
+
+type vec2 struct{ X, Y float64 }
+
+func (v vec2) add(o vec2) vec2      { return vec2{v.X + o.X, v.Y + o.Y} }
+func (v vec2) sub(o vec2) vec2      { return vec2{v.X - o.X, v.Y - o.Y} }
+func (v vec2) scale(f float64) vec2 { return vec2{v.X * f, v.Y * f} }
+func (v vec2) len() float64         { return math.Hypot(v.X, v.Y) }
+func (v vec2) perp() vec2           { return vec2{-v.Y, v.X} }
+
+// strokeSegment preenche o retângulo de espessura w entre a e b. As junções
+// arredondadas ficam por conta de fillDisc.
+func strokeSegment(mask *image.Alpha, a, b vec2, w float64) {
+	d := b.sub(a)
+	l := d.len()
+	if l == 0 {
+		return
+	}
+	n := d.perp().scale(w / 2 / l)
+	fillPaths(mask, [][]vec2{{a.add(n), b.add(n), b.sub(n), a.sub(n)}})
+}
+
+func fillDisc(mask *image.Alpha, c vec2, r float64) {
+	fillPaths(mask, [][]vec2{ellipsePath(c, r, r, false)})
+}
+
+func ellipsePath(c vec2, rx, ry float64, reverse bool) []vec2 {
+	n := int(math.Max(16, math.Min(128, (rx+ry)/2)))
+	pts := make([]vec2, n)
+	for i := range pts {
+		t := 2 * math.Pi * float64(i) / float64(n)
+		if reverse {
+			t = -t
+		}
+		pts[i] = vec2{c.X + rx*math.Cos(t), c.Y + ry*math.Sin(t)}
+	}
+	return pts
+}
+
+// fillPaths rasteriza os polígonos (regra não-zero) somando-os à máscara.
+// O rasterizador cobre só a caixa dos pontos, não a imagem inteira.
+func fillPaths(mask *image.Alpha, paths [][]vec2) {
+	box := image.Rectangle{}
+	for _, p := range paths {
+		for _, v := range p {
+			pt := image.Rectangle{
+				Min: image.Pt(int(math.Floor(v.X)), int(math.Floor(v.Y))),
+				Max: image.Pt(int(math.Ceil(v.X))+1, int(math.Ceil(v.Y))+1),
+			}
+			box = box.Union(pt)
+		}
+	}
+	box = box.Intersect(mask.Rect)
+	if box.Empty() {
+		return
+	}
+	z := vector.NewRasterizer(box.Dx(), box.Dy())
+	z.DrawOp = draw.Over
+	ox, oy := float64(box.Min.X), float64(box.Min.Y)
+	for _, p := range paths {
+		if len(p) < 3 {
+			continue
+		}
+		z.MoveTo(float32(p[0].X-ox), float32(p[0].Y-oy))
+		for _, v := range p[1:] {
+			z.LineTo(float32(v.X-ox), float32(v.Y-oy))
+		}
+		z.ClosePath()
+	}
+	z.Draw(mask, box, image.Opaque, image.Point{})
+}
+
+// ---- modo de anotação (UI) ----
+
+// selectTool ativa uma ferramenta; só faz sentido com a seleção travada.
+func (a *App) selectTool(t annotationTool) {
+	if !a.hasSelection {
+		return
+	}
+	a.finishTextEdit()
+	a.tool = t
+	a.drawing = nil
+	if a.annoWidth == 0 {
+		a.annoWidth = defaultStrokeWidth
+	}
+	if a.textSize == 0 {
+		a.textSize = defaultTextSize
+		a.textPill = true
+	}
+	if t == toolNone {
+		a.infoMessage = "Modo de anotação encerrado. Arraste para ajustar a seleção."
+		return
+	}
+	if t == toolText {
+		a.infoMessage = "Texto: clique na seleção para escrever. C cor | -/+ tamanho | Tab fundo | Esc sai"
+		return
+	}
+	if t.isRedaction() {
+		a.infoMessage = "Tarja: " + t.String() + ". Arraste sobre o conteúdo a ocultar. Backspace desfaz | Esc sai"
+		return
+	}
+	a.infoMessage = "Ferramenta: " + t.String() + ". Arraste dentro da seleção. C cor | -/+ espessura | Backspace desfaz | Esc sai"
+}
+
+// updateAnnotating trata o mouse enquanto uma ferramenta está ativa: o
+// arrasto desenha dentro da seleção em vez de ajustá-la.
+func (a *App) updateAnnotating(mx, my int) {
+	sel := a.selectionRect()
+	p := clampPoint(image.Pt(mx, my), sel)
+	if a.tool == toolText {
+		if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) && image.Pt(mx, my).In(sel) && !a.onButton(mx, my) {
+			a.placeText(p)
+		}
+		return
+	}
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		if a.drawing == nil {
+			if !image.Pt(mx, my).In(sel) || a.onButton(mx, my) {
+				return
+			}
+			a.drawing = &annotation{
+				Tool:   a.tool,
+				Points: []image.Point{p, p},
+				Color:  a.annotationColor(),
+				Width:  a.annoWidth,
+			}
+			return
+		}
+		pts := a.drawing.Points
+		if a.tool != toolPen {
+			pts[len(pts)-1] = p
+		} else if pts[len(pts)-1] != p {
+			a.drawing.Points = append(pts, p)
+		}
+		return
+	}
+	if a.drawing != nil {
+		an := *a.drawing
+		a.drawing = nil
+		if an.valid() {
+			a.addAnnotation(an)
+		}
+	}
+}
+
+func (a *App) addAnnotation(an annotation) {
+	a.annotations = append(a.annotations, an)
+	a.record(annotationCmd{index: len(a.annotations) - 1, after: &an})
+	a.annoDirty = true
+	a.infoMessage = fmt.Sprintf("%d anotação(ões). Enter salva | Backspace remove a última", len(a.annotations))
+}
+
+func (a *App) removeLastAnnotation() {
+	if len(a.annotations) == 0 {
+		return
+	}
+	last := a.annotations[len(a.annotations)-1]
+	a.annotations = a.annotations[:len(a.annotations)-1]
+	a.record(annotationCmd{index: len(a.annotations), before: &last})
+	a.annoDirty = true
+	a.infoMessage = "Anotação removida."
+}
+
+func (a *App) annotationColor() color.RGBA {
+	return annotationColors[a.annoColor%len(annotationColors)]
+}
+
+func (a *App) onButton(x, y int) bool {
+	return a.saveBtn.Contains(x, y) || a.cancelBtn.Contains(x, y) || a.copyBtn.Contains(x, y)
+}
+
+func clampPoint(p image.Point, r image.Rectangle) image.Point {
+	p.X = max(r.Min.X, min(p.X, r.Max.X-1))
+	p.Y = max(r.Min.Y, min(p.Y, r.Max.Y-1))
+	return p
+}
+
+// drawAnnotationLayer mostra as anotações usando o mesmo rasterizador da
+// exportação, para que a prévia seja idêntica ao arquivo salvo. A camada só
+// é refeita quando as anotações ou a seleção mudam.
+func (a *App) drawAnnotationLayer(screen *ebiten.Image) {
+	sel := a.selectionRect()
+	if a.annoDirty || a.annoLayerRect != sel {
+		if a.annoLayer != nil {
+			a.annoLayer.Deallocate()
+			a.annoLayer = nil
+		}
+		a.annoDirty = false
+		a.annoLayerRect = sel
+		if len(a.annotations) > 0 {
+			area := image.Rectangle{}
+			for _, an := range a.annotations {
+				area = area.Union(an.bounds())
+			}
+			if area = area.Intersect(sel); !area.Empty() {
+				layer := image.NewRGBA(area)
+				draw.Draw(layer, area, a.rawBG, area.Min, draw.Src)
+				drawAnnotations(layer, a.annotations, image.Point{})
+				a.annoLayer = ebiten.NewImageFromImage(layer)
+				a.annoLayerPos = area.Min
+			}
+		}
+	}
+	if a.annoLayer != nil {
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(float64(a.annoLayerPos.X), float64(a.annoLayerPos.Y))
+		screen.DrawImage(a.annoLayer, op)
+	}
+
+	// forma em andamento: recompõe só a caixa dela (as tarjas precisam dos
+	// pixels originais e ficam por baixo das anotações já feitas)
+	if a.drawing != nil {
+		area := a.drawing.bounds().Intersect(sel)
+		if area.Empty() {
+			return
+		}
+		patch := image.NewRGBA(area)
+		draw.Draw(patch, area, a.rawBG, area.Min, draw.Src)
+		drawAnnotations(patch, append(a.annotations[:len(a.annotations):len(a.annotations)], *a.drawing), image.Point{})
+		img := ebiten.NewImageFromImage(patch)
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(float64(area.Min.X), float64(area.Min.Y))
+		screen.DrawImage(img, op)
+		img.Deallocate()
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+var annoRed = color.RGBA{R: 230, G: 40, B: 40, A: 255}
+
+func blankCanvas(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	fill(img, img.Bounds(), color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	return img
+}
+
+func isColor(img *image.RGBA, x, y int, c color.RGBA) bool {
+	return img.RGBAAt(x, y) == c
+}
+
+func TestAnnotationRectIsHollow(t *testing.T) {
+	img := blankCanvas(100, 100)
+	an := annotation{Tool: toolRect, Points: []image.Point{{20, 20}, {80, 70}}, Color: annoRed, Width: 4}
+	drawAnnotations(img, []annotation{an}, image.Point{})
+
+	for _, p := range []image.Point{{20, 20}, {50, 20}, {80, 45}, {50, 70}, {20, 45}} {
+		if !isColor(img, p.X, p.Y, annoRed) {
+			t.Fatalf("borda em %v não pintada: %v", p, img.RGBAAt(p.X, p.Y))
+		}
+	}
+	if isColor(img, 50, 45, annoRed) || img.RGBAAt(50, 45).G != 255 {
+		t.Fatalf("interior do retângulo foi pintado: %v", img.RGBAAt(50, 45))
+	}
+	if img.RGBAAt(10, 10).G != 255 {
+		t.Fatalf("fora do retângulo foi pintado")
+	}
+}
+
+func TestAnnotationEllipse(t *testing.T) {
+	img := blankCanvas(100, 100)
+	an := annotation{Tool: toolEllipse, Points: []image.Point{{10, 30}, {90, 70}}, Color: annoRed, Width: 4}
+	drawAnnotations(img, []annotation{an}, image.Point{})
+
+	// extremos dos eixos pintados, centro e cantos da caixa intactos
+	for _, p := range []image.Point{{10, 50}, {90, 50}, {50, 30}, {50, 70}} {
+		if !isColor(img, p.X, p.Y, annoRed) {
+			t.Fatalf("contorno em %v não pintado: %v", p, img.RGBAAt(p.X, p.Y))
+		}
+	}
+	for _, p := range []image.Point{{50, 50}, {12, 32}, {88, 68}} {
+		if img.RGBAAt(p.X, p.Y).G != 255 {
+			t.Fatalf("pixel %v não deveria ser pintado: %v", p, img.RGBAAt(p.X, p.Y))
+		}
+	}
+}
+
+func TestAnnotationLineAndArrow(t *testing.T) {
+	img := blankCanvas(120, 60)
+	line := annotation{Tool: toolLine, Points: []image.Point{{10, 10}, {110, 10}}, Color: annoRed, Width: 3}
+	arrow := annotation{Tool: toolArrow, Points: []image.Point{{10, 40}, {110, 40}}, Color: annoRed, Width: 3}
+	drawAnnotations(img, []annotation{line, arrow}, image.Point{})
+
+	if !isColor(img, 60, 10, annoRed) || img.RGBAAt(60, 14).G != 255 {
+		t.Fatalf("linha com espessura errada: %v %v", img.RGBAAt(60, 10), img.RGBAAt(60, 14))
+	}
+	// a ponta da seta é mais larga que a haste
+	if img.RGBAAt(60, 45).G != 255 {
+		t.Fatalf("haste larga demais")
+	}
+	if img.RGBAAt(100, 45).G > 100 {
+		t.Fatalf("ponta da seta ausente: %v", img.RGBAAt(100, 45))
+	}
+}
+
+func TestAnnotationPen(t *testing.T) {
+	img := blankCanvas(60, 60)
+	pen := annotation{Tool: toolPen, Points: []image.Point{{5, 5}, {30, 5}, {30, 50}}, Color: annoRed, Width: 4}
+	drawAnnotations(img, []annotation{pen}, image.Point{})
+	for _, p := range []image.Point{{15, 5}, {30, 5}, {30, 30}, {30, 50}} {
+		if !isColor(img, p.X, p.Y, annoRed) {
+			t.Fatalf("traço em %v não pintado: %v", p, img.RGBAAt(p.X, p.Y))
+		}
+	}
+	if img.RGBAAt(15, 30).G != 255 {
+		t.Fatalf("pixel fora do traço pintado")
+	}
+}
+
+func TestAnnotationValid(t *testing.T) {
+	cases := []struct {
+		an   annotation
+		want bool
+	}{
+		{annotation{Tool: toolRect, Points: []image.Point{{5, 5}, {5, 5}}}, false},
+		{annotation{Tool: toolPen, Points: []image.Point{{5, 5}}}, false},
+		{annotation{Tool: toolLine, Points: []image.Point{{5, 5}, {5, 9}}}, true},
+	}
+	for i, c := range cases {
+		if got := c.an.valid(); got != c.want {
+			t.Fatalf("caso %d: got %v want %v", i, got, c.want)
+		}
+	}
+}
+
+func TestSelectionImageIncludesAnnotations(t *testing.T) {
+	raw := blankCanvas(100, 100)
+	app := &App{rawBG: raw}
+	app.selX0, app.selY0, app.selX1, app.selY1 = 20, 20, 60, 60
+	app.hasSelection = true
+	// a linha passa da borda da seleção: só o trecho interno é exportado
+	app.addAnnotation(annotation{Tool: toolLine, Points: []image.Point{{0, 40}, {99, 40}}, Color: annoRed, Width: 2})
+
+	out := app.selectionImage()
+	if out.Bounds() != image.Rect(0, 0, 40, 40) {
+		t.Fatalf("bounds: %v", out.Bounds())
+	}
+	if !isColor(out, 0, 20, annoRed) || !isColor(out, 39, 20, annoRed) {
+		t.Fatalf("linha não rasterizada na exportação: %v %v", out.RGBAAt(0, 20), out.RGBAAt(39, 20))
+	}
+	if out.RGBAAt(20, 5).G != 255 {
+		t.Fatalf("pixel fora da anotação alterado")
+	}
+	// rawBG continua intacto
+	if raw.RGBAAt(40, 40).G != 255 {
+		t.Fatalf("rawBG foi modificado")
+	}
+}
+
+func TestAnnotationToolsRequireSelection(t *testing.T) {
+	app := &App{rawBG: blankCanvas(50, 50)}
+	app.selectTool(toolArrow)
+	if app.tool != toolNone {
+		t.Fatalf("ferramenta ativada sem seleção")
+	}
+	app.selX0, app.selY0, app.selX1, app.selY1 = 0, 0, 30, 30
+	app.hasSelection = true
+	app.selectTool(toolArrow)
+	if app.tool != toolArrow || app.annoWidth != defaultStrokeWidth {
+		t.Fatalf("tool=%v width=%d", app.tool, app.annoWidth)
+	}
+
+	app.addAnnotation(annotation{Tool: toolArrow, Points: []image.Point{{1, 1}, {20, 20}}})
+	app.addAnnotation(annotation{Tool: toolRect, Points: []image.Point{{1, 1}, {20, 20}}})
+	app.removeLastAnnotation()
+	if len(app.annotations) != 1 || app.annotations[0].Tool != toolArrow {
+		t.Fatalf("remoção da última anotação falhou: %+v", app.annotations)
+	}
+
+	app.clearSelection()
+	if app.tool != toolNone || len(app.annotations) != 0 {
+		t.Fatalf("clearSelection deveria descartar anotações")
+	}
+}
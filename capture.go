@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg" // permite GST_FAKE_CAPTURE com arquivos JPEG
+	_ "image/png"
+	"os"
+	"sync"
+
+	"github.com/kbinani/screenshot"
+)
+
+// Capturer abstrai o backend de captura de tela. Todas as coordenadas são do
+// desktop virtual (displays podem ter origem negativa).
+type Capturer interface {
+	// Displays lista os retângulos dos displays ativos.
+	Displays() []image.Rectangle
+	// CaptureRect captura uma região do desktop virtual.
+	CaptureRect(r image.Rectangle) (*image.RGBA, error)
+	// CaptureAll captura a união de todos os displays em uma única imagem
+	// com origem em (0,0).
+	CaptureAll() (*image.RGBA, error)
+}
+
+// newCapturer escolhe o backend: GST_FAKE_CAPTURE=<arquivo> usa uma imagem
+// do disco no lugar da tela (útil em CI); senão usa kbinani/screenshot.
+func newCapturer() (Capturer, error) {
+	if path := os.Getenv("GST_FAKE_CAPTURE"); path != "" {
+		return newFileCapturer(path)
+	}
+	return screenshotCapturer{}, nil
+}
+
+// ---- backend real (kbinani/screenshot) ----
+
+type screenshotCapturer struct{}
+
+func (screenshotCapturer) Displays() []image.Rectangle {
+	n := screenshot.NumActiveDisplays()
+	displays := make([]image.Rectangle, 0, max(n, 0))
+	for i := 0; i < n; i++ {
+		displays = append(displays, screenshot.GetDisplayBounds(i))
+	}
+	return displays
+}
+
+func (screenshotCapturer) CaptureRect(r image.Rectangle) (*image.RGBA, error) {
+	img, err := screenshot.CaptureRect(r)
+	if err != nil {
+		return nil, newCaptureError(-1, err)
+	}
+	return img, nil
+}
+
+func (s screenshotCapturer) CaptureAll() (*image.RGBA, error) {
+	return composeDisplays(context.Background(), s, s.Displays())
+}
+
+// ---- backend falso (memória/arquivo) ----
+
+// fakeCapturer serve capturas a partir de uma imagem em memória que
+// representa o desktop virtual inteiro.
+type fakeCapturer struct {
+	desktop  *image.RGBA
+	mu       sync.Mutex // protege displays (SetDisplays simula hotplug)
+	displays []image.Rectangle
+}
+
+// newFakeCapturer cria um backend em memória. Sem displays informados, o
+// desktop inteiro vira um único display.
+func newFakeCapturer(desktop *image.RGBA, displays ...image.Rectangle) *fakeCapturer {
+	if len(displays) == 0 {
+		displays = []image.Rectangle{desktop.Bounds()}
+	}
+	return &fakeCapturer{desktop: desktop, displays: displays}
+}
+
+// newFileCapturer carrega uma imagem do disco como desktop de um display só.
+func newFileCapturer(path string) (*fakeCapturer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decodificar %s: %w", path, err)
+	}
+	desktop := image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(desktop, desktop.Bounds(), img, img.Bounds().Min, draw.Src)
+	return newFakeCapturer(desktop), nil
+}
+
+func (f *fakeCapturer) Displays() []image.Rectangle {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]image.Rectangle(nil), f.displays...)
+}
+
+// SetDisplays troca os displays ativos, como se um monitor tivesse sido
+// conectado ou desconectado.
+func (f *fakeCapturer) SetDisplays(displays ...image.Rectangle) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.displays = append([]image.Rectangle(nil), displays...)
+}
+
+func (f *fakeCapturer) CaptureRect(r image.Rectangle) (*image.RGBA, error) {
+	if r.Empty() || !r.In(f.desktop.Bounds()) {
+		return nil, &CaptureError{Kind: errDisplayGone, Display: -1, Err: fmt.Errorf("região %v fora do desktop %v", r, f.desktop.Bounds())}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), f.desktop, r.Min, draw.Src)
+	return dst, nil
+}
+
+func (f *fakeCapturer) CaptureAll() (*image.RGBA, error) {
+	return composeDisplays(context.Background(), f, f.Displays())
+}
+
+// ---- helpers ----
+
+// virtualBounds retorna a união dos displays (desktop virtual).
+func virtualBounds(displays []image.Rectangle) image.Rectangle {
+	var vb image.Rectangle
+	for _, b := range displays {
+		vb = vb.Union(b)
+	}
+	return vb
+}
+
+// composeDisplays captura os displays em paralelo, cada um direto na sua
+// posição de um canvas alocado uma vez, com origem em (0,0) e os offsets
+// relativos do desktop virtual. Se alguns displays falham, devolve o canvas
+// com os que deram certo e um erro com um *CaptureError por falha; se todos
+// falham, devolve só o erro. Com ctx cancelado, os displays que ainda não
+// começaram nem chegam ao backend e o resultado é ctx.Err(); uma chamada ao
+// backend já em curso não é interrompível e termina sozinha.
+func composeDisplays(ctx context.Context, c Capturer, displays []image.Rectangle) (*image.RGBA, error) {
+	if len(displays) == 0 {
+		return nil, &CaptureError{Kind: errDisplayGone, Display: -1, Err: errors.New("nenhum display ativo")}
+	}
+	vb := virtualBounds(displays)
+	dst := image.NewRGBA(image.Rect(0, 0, vb.Dx(), vb.Dy()))
+
+	// displays espelhados se sobrepõem; aí a cópia para o canvas é serializada
+	var drawMu *sync.Mutex
+	if displaysOverlap(displays) {
+		drawMu = &sync.Mutex{}
+	}
+	errs := make([]error, len(displays))
+	var wg sync.WaitGroup
+	for i, b := range displays {
+		wg.Add(1)
+		go func(i int, b image.Rectangle) {
+			defer wg.Done()
+			if ctx.Err() != nil {
+				return
+			}
+			img, err := c.CaptureRect(b)
+			if err != nil {
+				errs[i] = newCaptureError(i, err)
+				return
+			}
+			if ctx.Err() != nil {
+				return // cancelado enquanto o backend trabalhava
+			}
+			at := b.Min.Sub(vb.Min)
+			r := image.Rectangle{Min: at, Max: at.Add(img.Bounds().Size())}
+			if drawMu != nil {
+				drawMu.Lock()
+				defer drawMu.Unlock()
+			}
+			draw.Draw(dst, r, img, img.Bounds().Min, draw.Src)
+		}(i, b)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	err := errors.Join(errs...)
+	if failed == len(displays) {
+		return nil, err
+	}
+	return dst, err
+}
+
+func displaysOverlap(displays []image.Rectangle) bool {
+	for i := range displays {
+		for j := i + 1; j < len(displays); j++ {
+			if displays[i].Overlaps(displays[j]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// captureDisplay captura o display index e devolve a imagem com origem em
+// (0,0). Os erros são sempre *CaptureError; um índice que não existe mais
+// (monitor desconectado) é errDisplayGone.
+func captureDisplay(c Capturer, index int) (*image.RGBA, error) {
+	displays := c.Displays()
+	if index < 0 || index >= len(displays) {
+		return nil, &CaptureError{Kind: errDisplayGone, Display: index, Err: fmt.Errorf("%d ativos", len(displays))}
+	}
+	b := displays[index]
+	img, err := c.CaptureRect(b)
+	if err != nil {
+		return nil, newCaptureError(index, err)
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Src)
+	return dst, nil
+}
+
+func captureAllDisplays(c Capturer) (*image.RGBA, error) {
+	return c.CaptureAll()
+}
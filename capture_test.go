@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeDesktop monta um desktop virtual com dois displays lado a lado, o
+// primeiro com origem negativa, cada um pintado com uma cor sólida.
+func fakeDesktop() (*image.RGBA, []image.Rectangle) {
+	left := image.Rect(-40, 0, 0, 30)
+	right := image.Rect(0, 0, 60, 40)
+	desktop := image.NewRGBA(left.Union(right))
+	fill(desktop, left, color.RGBA{R: 255, A: 255})
+	fill(desktop, right, color.RGBA{B: 255, A: 255})
+	return desktop, []image.Rectangle{left, right}
+}
+
+func fill(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+func TestFakeCapturerCaptureRect(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	c := newFakeCapturer(desktop, displays...)
+
+	img, err := c.CaptureRect(image.Rect(-10, 5, 10, 15))
+	if err != nil {
+		t.Fatalf("CaptureRect: %v", err)
+	}
+	rectEq(t, img.Bounds(), image.Rect(0, 0, 20, 10))
+	if got := img.RGBAAt(0, 0); got.R != 255 {
+		t.Fatalf("pixel da esquerda inesperado: %v", got)
+	}
+	if got := img.RGBAAt(19, 0); got.B != 255 {
+		t.Fatalf("pixel da direita inesperado: %v", got)
+	}
+
+	if _, err := c.CaptureRect(image.Rect(50, 0, 100, 10)); err == nil {
+		t.Fatalf("esperava erro para região fora do desktop")
+	}
+}
+
+func TestCaptureDisplayUsesDisplayBounds(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	c := newFakeCapturer(desktop, displays...)
+
+	img, err := captureDisplay(c, 0)
+	if err != nil {
+		t.Fatalf("captureDisplay(0): %v", err)
+	}
+	rectEq(t, img.Bounds(), image.Rect(0, 0, 40, 30))
+	if got := img.RGBAAt(39, 29); got.R != 255 {
+		t.Fatalf("display 0 deveria ser vermelho: %v", got)
+	}
+	img, err = captureDisplay(c, 1)
+	if err != nil {
+		t.Fatalf("captureDisplay(1): %v", err)
+	}
+	rectEq(t, img.Bounds(), image.Rect(0, 0, 60, 40))
+	if got := img.RGBAAt(0, 0); got.B != 255 {
+		t.Fatalf("display 1 deveria ser azul: %v", got)
+	}
+
+	if _, err := captureDisplay(c, 2); err == nil {
+		t.Fatalf("esperava erro para display inexistente")
+	}
+}
+
+func TestCaptureAllDisplaysComposesVirtualDesktop(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	c := newFakeCapturer(desktop, displays...)
+
+	img, err := captureAllDisplays(c)
+	if err != nil {
+		t.Fatalf("captureAllDisplays: %v", err)
+	}
+	rectEq(t, img.Bounds(), image.Rect(0, 0, 100, 40))
+	if got := img.RGBAAt(0, 0); got.R != 255 {
+		t.Fatalf("canto esquerdo deveria vir do display 0: %v", got)
+	}
+	if got := img.RGBAAt(40, 0); got.B != 255 {
+		t.Fatalf("offset do display 1 incorreto: %v", got)
+	}
+	// área abaixo do display 0 não pertence a nenhum monitor
+	if got := img.RGBAAt(0, 35); got.A != 0 {
+		t.Fatalf("área fora dos displays deveria ficar transparente: %v", got)
+	}
+}
+
+// barrierCapturer só deixa uma captura terminar depois que todas as n
+// começaram: com captura sequencial, a primeira estoura o prazo e falha.
+type barrierCapturer struct {
+	*fakeCapturer
+	mu      sync.Mutex
+	started int
+	n       int
+	all     chan struct{}
+	fail    image.Rectangle // display que sempre falha
+}
+
+func (b *barrierCapturer) CaptureRect(r image.Rectangle) (*image.RGBA, error) {
+	b.mu.Lock()
+	b.started++
+	if b.started == b.n {
+		close(b.all)
+	}
+	b.mu.Unlock()
+	select {
+	case <-b.all:
+	case <-time.After(2 * time.Second):
+		return nil, errors.New("capturas não rodaram em paralelo")
+	}
+	if r == b.fail {
+		return nil, errors.New("permission denied")
+	}
+	return b.fakeCapturer.CaptureRect(r)
+}
+
+func (b *barrierCapturer) CaptureAll() (*image.RGBA, error) {
+	return composeDisplays(context.Background(), b, b.Displays())
+}
+
+func TestComposeDisplaysCapturesInParallel(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	c := &barrierCapturer{fakeCapturer: newFakeCapturer(desktop, displays...), n: len(displays), all: make(chan struct{})}
+	img, err := composeDisplays(context.Background(), c, displays)
+	if err != nil {
+		t.Fatalf("composeDisplays: %v", err)
+	}
+	if img.RGBAAt(0, 0).R != 255 || img.RGBAAt(40, 0).B != 255 {
+		t.Fatalf("displays fora da posição")
+	}
+}
+
+func TestComposeDisplaysReportsFailedDisplay(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	c := &barrierCapturer{fakeCapturer: newFakeCapturer(desktop, displays...), n: 2, all: make(chan struct{}), fail: displays[1]}
+	img, err := composeDisplays(context.Background(), c, displays)
+	var ce *CaptureError
+	if !errors.As(err, &ce) || ce.Display != 1 || !errors.Is(err, errPermissionDenied) {
+		t.Fatalf("esperava CaptureError de permissão do display 1: %v", err)
+	}
+	if img == nil || img.RGBAAt(0, 0).R != 255 {
+		t.Fatalf("o display que funcionou deveria vir na imagem")
+	}
+
+	// todos falhando: sem imagem
+	c = &barrierCapturer{fakeCapturer: newFakeCapturer(desktop, displays[1]), n: 1, all: make(chan struct{}), fail: displays[1]}
+	if img, err := composeDisplays(context.Background(), c, displays[1:]); img != nil || err == nil {
+		t.Fatalf("sem nenhum display capturado deveria falhar: %v", err)
+	}
+}
+
+func TestComposeDisplaysMirrored(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	mirrored := []image.Rectangle{displays[1], displays[1]}
+	img, err := composeDisplays(context.Background(), newFakeCapturer(desktop, displays...), mirrored)
+	if err != nil || img.Bounds() != image.Rect(0, 0, 60, 40) {
+		t.Fatalf("espelhados: %v, %v", img.Bounds(), err)
+	}
+}
+
+func TestNewFileCapturer(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 8, 6))
+	fill(src, src.Bounds(), color.RGBA{G: 200, A: 255})
+	path := filepath.Join(t.TempDir(), "desktop.png")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("criar arquivo: %v", err)
+	}
+	if err := png.Encode(f, src); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	_ = f.Close()
+
+	c, err := newFileCapturer(path)
+	if err != nil {
+		t.Fatalf("newFileCapturer: %v", err)
+	}
+	if ds := c.Displays(); len(ds) != 1 || ds[0] != src.Bounds() {
+		t.Fatalf("displays inesperados: %v", ds)
+	}
+	img, err := captureDisplay(c, 0)
+	if err != nil {
+		t.Fatalf("captureDisplay: %v", err)
+	}
+	if got := img.RGBAAt(7, 5); got.G != 200 {
+		t.Fatalf("pixel inesperado: %v", got)
+	}
+}
+
+func TestSwitchDisplayUsesCapturer(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	c := newFakeCapturer(desktop, displays...)
+	app := &App{capturer: c, displays: c.Displays()}
+	app.hasSelection = true
+
+	app.switchDisplay(1)
+	if !app.counting() {
+		t.Fatalf("a troca deveria agendar a recaptura com a janela escondida")
+	}
+	finishCapture(t, app)
+	if app.curDisp != 1 {
+		t.Fatalf("curDisp = %d; want 1", app.curDisp)
+	}
+	if app.hasSelection {
+		t.Fatalf("seleção deveria ser limpa ao trocar de monitor")
+	}
+	rectEq(t, app.rawBG.Bounds(), image.Rect(0, 0, 60, 40))
+
+	// índice inválido não altera nada
+	app.switchDisplay(5)
+	if app.curDisp != 1 {
+		t.Fatalf("curDisp alterado por índice inválido: %d", app.curDisp)
+	}
+}
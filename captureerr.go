@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/kbinani/screenshot"
+)
+
+// captureErrorKind classifica a falha de captura. Cada tipo também é um
+// erro, para uso com errors.Is:
+//
+//	errors.Is(err, errPermissionDenied)
+type captureErrorKind int
+
+const (
+	errCaptureFailed      captureErrorKind = iota // causa desconhecida
+	errPermissionDenied                           // sistema negou acesso à tela
+	errDisplayGone                                // display desconectado/inexistente
+	errBackendUnavailable                         // sem servidor gráfico ou plataforma sem suporte
+)
+
+func (k captureErrorKind) Error() string {
+	switch k {
+	case errPermissionDenied:
+		return "permissão negada para capturar a tela"
+	case errDisplayGone:
+		return "display indisponível"
+	case errBackendUnavailable:
+		return "backend de captura indisponível"
+	}
+	return "falha na captura"
+}
+
+// hint sugere ao usuário o que fazer para cada tipo de falha.
+func (k captureErrorKind) hint() string {
+	switch k {
+	case errPermissionDenied:
+		return "Autorize a gravação de tela (macOS: Ajustes > Privacidade; Wayland: portal de captura) e tente de novo."
+	case errDisplayGone:
+		return "O monitor foi desconectado. Use Q/E para escolher outro ou A para todos."
+	case errBackendUnavailable:
+		return "Nenhum servidor gráfico acessível (confira DISPLAY/WAYLAND_DISPLAY)."
+	}
+	return "Tente de novo; se persistir, rode 'go-screentake capture' no terminal para ver o erro."
+}
+
+// CaptureError é o erro devolvido pelas capturas. Display é o índice do
+// display envolvido, ou -1 quando não se aplica.
+type CaptureError struct {
+	Kind    captureErrorKind
+	Display int
+	Err     error
+}
+
+func (e *CaptureError) Error() string {
+	msg := e.Kind.Error()
+	if e.Display >= 0 {
+		msg = fmt.Sprintf("display %d: %s", e.Display, msg)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *CaptureError) Unwrap() error { return e.Err }
+
+// Is faz errors.Is(err, errDisplayGone) etc. funcionar.
+func (e *CaptureError) Is(target error) bool {
+	k, ok := target.(captureErrorKind)
+	return ok && k == e.Kind
+}
+
+// newCaptureError embrulha err preservando o tipo de um CaptureError interno
+// (e preenchendo o display, se ainda não tinha).
+func newCaptureError(display int, err error) *CaptureError {
+	var ce *CaptureError
+	if errors.As(err, &ce) {
+		out := *ce
+		if out.Display < 0 {
+			out.Display = display
+		}
+		return &out
+	}
+	return &CaptureError{Kind: classifyBackendError(err), Display: display, Err: err}
+}
+
+// classifyBackendError deduz o tipo pela mensagem do backend (o
+// kbinani/screenshot só devolve erros em texto). Só mensagens conhecidas
+// contam, ancoradas no início ou como frase inteira: uma palavra solta como
+// "display" aparece também em falhas comuns ("failed to capture display 1").
+func classifyBackendError(err error) captureErrorKind {
+	if errors.Is(err, screenshot.ErrUnsupported) {
+		return errBackendUnavailable
+	}
+	msg := strings.ToLower(err.Error())
+	if msg == "cannot capture display" { // macOS sem permissão de gravação de tela
+		return errPermissionDenied
+	}
+	for _, s := range []string{
+		"permission denied",       // EACCES
+		"operation not permitted", // EPERM
+		"org.freedesktop.portal.error.notallowed",
+	} {
+		if strings.Contains(msg, s) {
+			return errPermissionDenied
+		}
+	}
+	for _, s := range []string{
+		"dbus.sessionbus() failed", // Wayland sem sessão D-Bus
+		"empty display string",     // xgb: $DISPLAY vazio
+		"bad display string",
+		"cannot connect to ", // xgb: servidor X inacessível
+		"can't open display",
+		"x protocol authentication refused",
+		"unsupported auth protocol",
+	} {
+		if strings.HasPrefix(msg, s) {
+			return errBackendUnavailable
+		}
+	}
+	return errCaptureFailed
+}
+
+// ---- tela de erro (GUI) ----
+
+// updateCaptureError trata o teclado enquanto a tela de erro está aberta:
+// Enter/F5 tentam de novo, Q/E/A trocam o alvo e Esc sai.
+func (a *App) updateCaptureError() error {
+	now := time.Now()
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter), inpututil.IsKeyJustPressed(ebiten.KeyF5):
+		a.captureErr = nil
+		a.recapture(now)
+	case inpututil.IsKeyJustPressed(ebiten.KeyQ) && !a.modeAll && len(a.displays) > 0:
+		a.captureErr = nil
+		a.switchDisplay((a.curDisp - 1 + len(a.displays)) % len(a.displays))
+	case inpututil.IsKeyJustPressed(ebiten.KeyE) && !a.modeAll && len(a.displays) > 0:
+		a.captureErr = nil
+		a.switchDisplay((a.curDisp + 1) % len(a.displays))
+	case inpututil.IsKeyJustPressed(ebiten.KeyA):
+		a.captureErr = nil
+		a.modeAll = !a.modeAll
+		a.clearSelection()
+		a.history = history{}
+		a.recapture(now)
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		return ebiten.Termination
+	}
+	return nil
+}
+
+// drawCaptureError cobre a tela com o erro e as opções; nunca mostra (nem
+// deixa salvar) uma imagem em branco no lugar da captura.
+func (a *App) drawCaptureError(screen *ebiten.Image) {
+	b := screen.Bounds()
+	ebitenutil.DrawRect(screen, 0, 0, float64(b.Dx()), float64(b.Dy()), color.NRGBA{R: 30, G: 10, B: 10, A: 255})
+	kind := errCaptureFailed
+	var ce *CaptureError
+	if errors.As(a.captureErr, &ce) {
+		kind = ce.Kind
+	}
+	lines := []string{
+		"Não foi possível capturar a tela",
+		a.captureErr.Error(),
+		kind.hint(),
+		"Enter/F5: tentar de novo | Q/E: outro monitor | A: todos os monitores | Esc: sair",
+	}
+	scale := max(a.viewScale, 1)
+	y := float64(b.Dy())/2 - 40*scale
+	for _, l := range lines {
+		a.drawScaledLabel(screen, l, 24*scale, y, scale)
+		y += 24 * scale
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kbinani/screenshot"
+)
+
+// failingCapturer conhece os displays mas toda captura falha com err.
+type failingCapturer struct {
+	*fakeCapturer
+	err error
+}
+
+func (f *failingCapturer) CaptureRect(image.Rectangle) (*image.RGBA, error) {
+	return nil, newCaptureError(-1, f.err)
+}
+
+func TestClassifyBackendError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want captureErrorKind
+	}{
+		{errors.New("cannot capture display"), errPermissionDenied},                                                          // macOS sem gravação de tela
+		{errors.New("dbus.Store() failed: org.freedesktop.portal.Error.NotAllowed: denied"), errPermissionDenied},            // portal recusado
+		{errors.New("open /dev/fb0: permission denied"), errPermissionDenied},                                                // EACCES
+		{errors.New("dbus.SessionBus() failed: dial unix /run/user/1000/bus: connect: no such file"), errBackendUnavailable}, // Wayland sem sessão
+		{errors.New("empty display string"), errBackendUnavailable},                                                          // $DISPLAY não definido
+		{errors.New("cannot connect to :0: dial unix /tmp/.X11-unix/X0: connect: no such file or directory"), errBackendUnavailable},
+		{errors.New("Can't open display: :0"), errBackendUnavailable},
+		{errors.New("x protocol authentication refused: No protocol specified"), errBackendUnavailable},
+		{screenshot.ErrUnsupported, errBackendUnavailable},
+		{errors.New("BitBlt failed"), errCaptureFailed},
+		// quase iguais: falam de display/conexão, mas não são nenhum dos casos
+		{errors.New("display index out of range"), errCaptureFailed},
+		{errors.New("failed to capture display 1"), errCaptureFailed},
+		{errors.New("cannot capture display 2 while locked"), errCaptureFailed},
+		{errors.New("xinerama: reply: connection reset by peer"), errCaptureFailed},
+		{errors.New("png.Decode(/tmp/shot.png) failed: unexpected EOF"), errCaptureFailed},
+	}
+	for _, tc := range tests {
+		if got := classifyBackendError(tc.err); got != tc.want {
+			t.Errorf("classifyBackendError(%q) = %v; want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestCaptureErrorIs(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	c := newFakeCapturer(desktop, displays...)
+
+	_, err := captureDisplay(c, 7)
+	if !errors.Is(err, errDisplayGone) || errors.Is(err, errPermissionDenied) {
+		t.Fatalf("display inexistente deveria ser errDisplayGone: %v", err)
+	}
+
+	denied := &failingCapturer{fakeCapturer: c, err: errors.New("permission denied")}
+	_, err = captureDisplay(denied, 1)
+	var ce *CaptureError
+	if !errors.As(err, &ce) || ce.Display != 1 || ce.Kind != errPermissionDenied {
+		t.Fatalf("esperava permissão negada no display 1: %#v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "display 1: permissão negada") {
+		t.Fatalf("mensagem inesperada: %q", err)
+	}
+}
+
+func TestFailedCaptureShowsErrorScreen(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	fc := newFakeCapturer(desktop, displays...)
+	placeholder := blankCanvas(40, 30)
+	app := &App{
+		capturer: &failingCapturer{fakeCapturer: fc, err: errors.New("cannot capture display")},
+		displays: displays,
+		rawBG:    placeholder,
+	}
+	app.startCapture()
+	waitCapture(t, app)
+	if !errors.Is(app.captureErr, errPermissionDenied) {
+		t.Fatalf("captureErr = %v; want permissão negada", app.captureErr)
+	}
+	if app.captured || app.rawBG != placeholder {
+		t.Fatalf("uma captura que falhou não pode virar o fundo")
+	}
+
+	// tentar de novo com o backend de volta
+	app.capturer = fc
+	app.captureErr = nil
+	app.recapture(app.delayEnd)
+	finishCapture(t, app)
+	if app.captureErr != nil || !app.captured {
+		t.Fatalf("nova captura deveria limpar o erro: %v", app.captureErr)
+	}
+	rectEq(t, app.rawBG.Bounds(), image.Rect(0, 0, 40, 30))
+}
+
+func TestRunCaptureFailureWritesNothing(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	c := &failingCapturer{fakeCapturer: newFakeCapturer(desktop, displays...), err: errors.New("dbus.SessionBus() failed")}
+	out := filepath.Join(t.TempDir(), "shot.png")
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI(c, defaultConfig(), []string{"capture", "--out", out}, &stdout, &stderr); code != exitFailure {
+		t.Fatalf("exit=%d; want %d", code, exitFailure)
+	}
+	if !strings.Contains(stderr.String(), "backend de captura indisponível") {
+		t.Fatalf("stderr deveria dizer o tipo da falha: %q", stderr.String())
+	}
+	if _, err := os.Stat(out); err == nil {
+		t.Fatalf("nada deveria ser gravado")
+	}
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Códigos de saída do modo CLI
+const (
+	exitOK      = 0
+	exitFailure = 1 // falha de captura ou de gravação
+	exitUsage   = 2 // argumentos inválidos
+)
+
+const cliUsage = `Uso: go-screentake [comando] [opções]
+
+Sem comando, abre a janela de seleção interativa.
+
+Comandos:
+  capture         captura a tela sem interação e grava a imagem
+  list-displays   lista os monitores e o desktop virtual
+  help            mostra esta ajuda
+
+Use "go-screentake <comando> -h" para ver as opções de cada comando.
+`
+
+// sleep é time.Sleep; os testes trocam para não esperar de verdade.
+var sleep = time.Sleep
+
+// runCLI executa um subcomando não interativo e devolve o código de saída.
+func runCLI(c Capturer, cfg Config, args []string, stdout, stderr io.Writer) int {
+	switch args[0] {
+	case "capture":
+		return runCapture(c, cfg, args[1:], stdout, stderr)
+	case "list-displays":
+		return runListDisplays(c, args[1:], stdout, stderr)
+	case "help":
+		fmt.Fprint(stdout, cliUsage)
+		return exitOK
+	default:
+		fmt.Fprintf(stderr, "comando desconhecido: %q\n\n%s", args[0], cliUsage)
+		return exitUsage
+	}
+}
+
+func runCapture(c Capturer, cfg Config, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("capture", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	display := fs.Int("display", 0, "índice do monitor (começa em 0)")
+	all := fs.Bool("all", false, "captura todos os monitores (desktop virtual)")
+	region := fs.String("region", "", "recorte x,y,w,h relativo à imagem capturada")
+	trim := fs.Bool("trim", false, "remove as bordas de cor uniforme (depois de --region)")
+	delay := fs.Int("delay", 0, "espera N segundos antes de capturar (menus, tooltips)")
+	out := fs.String("out", "", "arquivo de saída (\"-\" para stdout; padrão: <dir>/<name>.<ext>)")
+	formatName := fs.String("format", "", "formato: png, jpeg, gif, bmp, tiff (padrão: extensão de --out ou config)")
+	quality := fs.Int("quality", cfg.Quality, "qualidade JPEG (1-100)")
+	dir := fs.String("dir", cfg.Dir, "diretório de saída quando --out não é informado")
+	nameTmpl := fs.String("name", cfg.NameTemplate, "template do nome quando --out não é informado")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "argumentos inesperados: %s\n", strings.Join(fs.Args(), " "))
+		return exitUsage
+	}
+
+	format, err := lookupFormat(cfg.Format)
+	if *formatName != "" {
+		format, err = lookupFormat(*formatName)
+	} else if f, ok := formatForPath(*out); ok {
+		format = f
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	if f, ok := formatForPath(*out); ok && *formatName != "" && f.Name != format.Name {
+		// gravar JPEG em shot.png engana quem abrir o arquivo
+		fmt.Fprintf(stderr, "--format %s não combina com a extensão de %s\n", format.Name, *out)
+		return exitUsage
+	}
+	if err := checkQuality(*quality); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	var rect image.Rectangle
+	if *region != "" {
+		r, err := parseRegion(*region)
+		if err != nil {
+			fmt.Fprintln(stderr, "região inválida:", err)
+			return exitUsage
+		}
+		rect = r
+	}
+
+	if *delay < 0 {
+		fmt.Fprintln(stderr, "--delay não pode ser negativo")
+		return exitUsage
+	}
+	for left := *delay; left > 0; left-- {
+		fmt.Fprintf(stderr, "capturando em %d…\n", left)
+		sleep(time.Second)
+	}
+
+	var raw *image.RGBA
+	if *all {
+		raw, err = captureAllDisplays(c)
+	} else {
+		raw, err = captureDisplay(c, *display)
+	}
+	if err != nil {
+		// uma linha por display que falhou
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintln(stderr, "erro ao capturar:", line)
+		}
+		if raw != nil {
+			fmt.Fprintln(stderr, "captura incompleta; nada foi gravado")
+		}
+		return exitFailure
+	}
+
+	var img image.Image = raw
+	crop := raw.Bounds()
+	if *region != "" {
+		if !rect.In(raw.Bounds()) {
+			fmt.Fprintf(stderr, "região %v fora da captura %v\n", rect, raw.Bounds())
+			return exitUsage
+		}
+		crop = rect
+	}
+	if *trim {
+		crop = trimUniform(raw, crop, trimTolerance)
+	}
+	if crop != raw.Bounds() {
+		img = raw.SubImage(crop)
+	}
+
+	data, err := encodeImage(img, format, encodeOptions{Quality: *quality})
+	if err != nil {
+		fmt.Fprintln(stderr, "erro ao codificar imagem:", err)
+		return exitFailure
+	}
+
+	if *out == "-" {
+		if _, err := stdout.Write(data); err != nil {
+			fmt.Fprintln(stderr, "erro ao escrever na saída padrão:", err)
+			return exitFailure
+		}
+		return exitOK
+	}
+	path := *out
+	if path == "" {
+		displayName := strconv.Itoa(*display)
+		if *all {
+			displayName = "all"
+		}
+		d := outputDir(*dir)
+		vars := newNameVars(time.Now(), displayName, img.Bounds().Dx(), img.Bounds().Dy())
+		path = filepath.Join(d, fileName(d, *nameTmpl, vars, format.Ext))
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		fmt.Fprintln(stderr, "falha ao criar diretório de saída:", err)
+		return exitFailure
+	}
+	if *out != "" {
+		err = writeFileAtomic(path, data)
+	} else {
+		path, err = writeFileUnique(path, data)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "erro ao salvar arquivo:", err)
+		return exitFailure
+	}
+	fmt.Fprintln(stdout, path)
+	return exitOK
+}
+
+// rectInfo é a forma serializada de um retângulo.
+type rectInfo struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+func newRectInfo(r image.Rectangle) rectInfo {
+	return rectInfo{X: r.Min.X, Y: r.Min.Y, Width: r.Dx(), Height: r.Dy()}
+}
+
+type displayInfo struct {
+	Index  int      `json:"index"`
+	Bounds rectInfo `json:"bounds"`
+	// Offset é a posição do display dentro da captura --all (origem do
+	// desktop virtual em 0,0).
+	OffsetX int `json:"offset_x"`
+	OffsetY int `json:"offset_y"`
+}
+
+type displayReport struct {
+	Displays []displayInfo `json:"displays"`
+	Virtual  rectInfo      `json:"virtual"`
+}
+
+func newDisplayReport(displays []image.Rectangle) displayReport {
+	vb := virtualBounds(displays)
+	rep := displayReport{Displays: make([]displayInfo, 0, len(displays)), Virtual: newRectInfo(vb)}
+	for i, b := range displays {
+		off := b.Min.Sub(vb.Min)
+		rep.Displays = append(rep.Displays, displayInfo{Index: i, Bounds: newRectInfo(b), OffsetX: off.X, OffsetY: off.Y})
+	}
+	return rep
+}
+
+func runListDisplays(c Capturer, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("list-displays", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	asJSON := fs.Bool("json", false, "saída em JSON")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "argumentos inesperados: %s\n", strings.Join(fs.Args(), " "))
+		return exitUsage
+	}
+
+	displays := c.Displays()
+	if len(displays) == 0 {
+		fmt.Fprintln(stderr, "Nenhum display ativo.")
+		return exitFailure
+	}
+	rep := newDisplayReport(displays)
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(rep); err != nil {
+			fmt.Fprintln(stderr, "erro ao gerar JSON:", err)
+			return exitFailure
+		}
+		return exitOK
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DISPLAY\tX\tY\tLARGURA\tALTURA\tOFFSET")
+	for _, d := range rep.Displays {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%d\t%d,%d\n", d.Index, d.Bounds.X, d.Bounds.Y, d.Bounds.Width, d.Bounds.Height, d.OffsetX, d.OffsetY)
+	}
+	v := rep.Virtual
+	fmt.Fprintf(tw, "virtual\t%d\t%d\t%d\t%d\t-\n", v.X, v.Y, v.Width, v.Height)
+	if err := tw.Flush(); err != nil {
+		fmt.Fprintln(stderr, "erro ao escrever saída:", err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// parseRegion interpreta "x,y,w,h" como um retângulo.
+func parseRegion(s string) (image.Rectangle, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return image.Rectangle{}, fmt.Errorf("esperado x,y,w,h, recebido %q", s)
+	}
+	var v [4]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return image.Rectangle{}, fmt.Errorf("valor %q não é inteiro", p)
+		}
+		v[i] = n
+	}
+	if v[2] <= 0 || v[3] <= 0 {
+		return image.Rectangle{}, fmt.Errorf("largura e altura devem ser positivas")
+	}
+	return image.Rect(v[0], v[1], v[0]+v[2], v[1]+v[3]), nil
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRegion(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    image.Rectangle
+		wantErr bool
+	}{
+		{"100,100,800,600", image.Rect(100, 100, 900, 700), false},
+		{" 0, 0, 1, 1 ", image.Rect(0, 0, 1, 1), false},
+		{"-10,5,20,20", image.Rect(-10, 5, 10, 25), false},
+		{"1,2,3", image.Rectangle{}, true},
+		{"a,b,c,d", image.Rectangle{}, true},
+		{"0,0,0,10", image.Rectangle{}, true},
+		{"0,0,10,-1", image.Rectangle{}, true},
+	}
+	for _, tc := range tests {
+		got, err := parseRegion(tc.in)
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("parseRegion(%q) err=%v; wantErr=%v", tc.in, err, tc.wantErr)
+		}
+		if got != tc.want {
+			t.Fatalf("parseRegion(%q) = %v; want %v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestRunCaptureWritesRegion(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	c := newFakeCapturer(desktop, displays...)
+	out := filepath.Join(t.TempDir(), "sub", "shot.png")
+
+	var stdout, stderr bytes.Buffer
+	code := runCLI(c, defaultConfig(), []string{"capture", "--display", "1", "--region", "5,5,10,20", "--out", out}, &stdout, &stderr)
+	if code != exitOK {
+		t.Fatalf("exit=%d stderr=%q", code, stderr.String())
+	}
+	if strings.TrimSpace(stdout.String()) != out {
+		t.Fatalf("stdout deveria conter o caminho salvo: %q", stdout.String())
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatalf("abrir saída: %v", err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("decodificar: %v", err)
+	}
+	if img.Bounds().Dx() != 10 || img.Bounds().Dy() != 20 {
+		t.Fatalf("dimensões inesperadas: %v", img.Bounds())
+	}
+	if _, _, b, _ := img.At(img.Bounds().Min.X, img.Bounds().Min.Y).RGBA(); b>>8 != 255 {
+		t.Fatalf("recorte deveria vir do display 1 (azul)")
+	}
+}
+
+func TestRunCaptureAllToStdout(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	c := newFakeCapturer(desktop, displays...)
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI(c, defaultConfig(), []string{"capture", "--all", "--out", "-"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit=%d stderr=%q", code, stderr.String())
+	}
+	img, err := png.Decode(&stdout)
+	if err != nil {
+		t.Fatalf("stdout não é PNG: %v", err)
+	}
+	rectEq(t, img.Bounds(), image.Rect(0, 0, 100, 40))
+}
+
+func TestRunCaptureTrim(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	fill(desktop, image.Rect(10, 10, 30, 25), color.RGBA{G: 255, A: 255}) // "janela" no display 1
+	c := newFakeCapturer(desktop, displays...)
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI(c, defaultConfig(), []string{"capture", "--display", "1", "--trim", "--out", "-"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit=%d stderr=%q", code, stderr.String())
+	}
+	img, err := png.Decode(&stdout)
+	if err != nil {
+		t.Fatalf("stdout não é PNG: %v", err)
+	}
+	if img.Bounds().Dx() != 20 || img.Bounds().Dy() != 15 {
+		t.Fatalf("--trim deveria enquadrar a janela: %v", img.Bounds())
+	}
+}
+
+func TestRunCaptureDelay(t *testing.T) {
+	var slept []time.Duration
+	sleep = func(d time.Duration) { slept = append(slept, d) }
+	defer func() { sleep = time.Sleep }()
+
+	desktop, displays := fakeDesktop()
+	c := newFakeCapturer(desktop, displays...)
+	var stdout, stderr bytes.Buffer
+	if code := runCLI(c, defaultConfig(), []string{"capture", "--delay", "3", "--out", "-"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit=%d stderr=%q", code, stderr.String())
+	}
+	if len(slept) != 3 || !strings.Contains(stderr.String(), "capturando em 1") {
+		t.Fatalf("contagem: %v %q", slept, stderr.String())
+	}
+
+	if code := runCLI(c, defaultConfig(), []string{"capture", "--delay", "-1"}, &stdout, &stderr); code != exitUsage {
+		t.Fatalf("--delay negativo: exit=%d", code)
+	}
+}
+
+func TestRunCLIExitCodes(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	c := newFakeCapturer(desktop, displays...)
+	dir := t.TempDir()
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"unknown_command", []string{"bogus"}, exitUsage},
+		{"bad_flag", []string{"capture", "--nope"}, exitUsage},
+		{"bad_region", []string{"capture", "--region", "1,2"}, exitUsage},
+		{"region_outside", []string{"capture", "--region", "0,0,500,500", "--out", filepath.Join(dir, "a.png")}, exitUsage},
+		{"quality_zero", []string{"capture", "--quality", "0", "--out", filepath.Join(dir, "q.jpg")}, exitUsage},
+		{"quality_too_high", []string{"capture", "--quality", "500", "--out", filepath.Join(dir, "q.jpg")}, exitUsage},
+		{"missing_display", []string{"capture", "--display", "7", "--out", filepath.Join(dir, "b.png")}, exitFailure},
+		{"help", []string{"help"}, exitOK},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if got := runCLI(c, defaultConfig(), tc.args, &stdout, &stderr); got != tc.want {
+				t.Fatalf("exit=%d; want %d (stderr=%q)", got, tc.want, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunListDisplaysJSON(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	c := newFakeCapturer(desktop, displays...)
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI(c, defaultConfig(), []string{"list-displays", "--json"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit=%d stderr=%q", code, stderr.String())
+	}
+	var rep displayReport
+	if err := json.Unmarshal(stdout.Bytes(), &rep); err != nil {
+		t.Fatalf("JSON inválido: %v\n%s", err, stdout.String())
+	}
+	if len(rep.Displays) != 2 {
+		t.Fatalf("esperava 2 displays, veio %d", len(rep.Displays))
+	}
+	left := rep.Displays[0]
+	if left.Bounds != (rectInfo{X: -40, Y: 0, Width: 40, Height: 30}) || left.OffsetX != 0 || left.OffsetY != 0 {
+		t.Fatalf("display 0 inesperado: %+v", left)
+	}
+	if right := rep.Displays[1]; right.OffsetX != 40 || right.OffsetY != 0 {
+		t.Fatalf("offset do display 1 inesperado: %+v", right)
+	}
+	if rep.Virtual != (rectInfo{X: -40, Y: 0, Width: 100, Height: 40}) {
+		t.Fatalf("desktop virtual inesperado: %+v", rep.Virtual)
+	}
+}
+
+func TestRunListDisplaysTable(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	c := newFakeCapturer(desktop, displays...)
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI(c, defaultConfig(), []string{"list-displays"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit=%d stderr=%q", code, stderr.String())
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("esperava cabeçalho + 2 displays + virtual:\n%s", stdout.String())
+	}
+	if f := strings.Fields(lines[1]); len(f) != 6 || f[1] != "-40" || f[5] != "0,0" {
+		t.Fatalf("linha do display 0 inesperada: %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[3], "virtual") {
+		t.Fatalf("última linha deveria ser o desktop virtual: %q", lines[3])
+	}
+}
+
+func TestRunCaptureInfersFormatFromOut(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	c := newFakeCapturer(desktop, displays...)
+	out := filepath.Join(t.TempDir(), "shot.tiff")
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI(c, defaultConfig(), []string{"capture", "--out", out}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit=%d stderr=%q", code, stderr.String())
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("ler saída: %v", err)
+	}
+	if len(data) < 4 || string(data[:4]) != "II*\x00" {
+		t.Fatalf("saída não é TIFF: % x", data[:min(len(data), 4)])
+	}
+	if code := runCLI(c, defaultConfig(), []string{"capture", "--format", "xcf", "--out", out}, &stdout, &stderr); code != exitUsage {
+		t.Fatalf("formato inválido deveria ser erro de uso, exit=%d", code)
+	}
+}
+
+func TestRunCaptureFormatMustMatchOut(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	c := newFakeCapturer(desktop, displays...)
+	dir := t.TempDir()
+
+	var stdout, stderr bytes.Buffer
+	pngOut := filepath.Join(dir, "shot.png")
+	if code := runCLI(c, defaultConfig(), []string{"capture", "--format", "jpeg", "--out", pngOut}, &stdout, &stderr); code != exitUsage {
+		t.Fatalf("--format jpeg com .png deveria ser erro de uso, exit=%d", code)
+	}
+	if _, err := os.Stat(pngOut); err == nil {
+		t.Fatalf("nada deveria ser gravado")
+	}
+
+	// mesma extensão (ou um apelido dela) e extensão desconhecida passam
+	for _, name := range []string{"shot.jpg", "shot.img"} {
+		out := filepath.Join(dir, name)
+		if code := runCLI(c, defaultConfig(), []string{"capture", "--format", "jpeg", "--out", out}, &stdout, &stderr); code != exitOK {
+			t.Fatalf("%s: exit=%d stderr=%q", name, code, stderr.String())
+		}
+		data, err := os.ReadFile(out)
+		if err != nil || len(data) < 2 || data[0] != 0xff || data[1] != 0xd8 {
+			t.Fatalf("%s: saída não é JPEG (%v)", name, err)
+		}
+	}
+}
+
+func TestRunCaptureAllReportsFailedDisplay(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	c := &barrierCapturer{fakeCapturer: newFakeCapturer(desktop, displays...), n: 2, all: make(chan struct{}), fail: displays[0]}
+	out := filepath.Join(t.TempDir(), "all.png")
+
+	var stdout, stderr bytes.Buffer
+	if code := runCLI(c, defaultConfig(), []string{"capture", "--all", "--out", out}, &stdout, &stderr); code != exitFailure {
+		t.Fatalf("exit=%d; want %d", code, exitFailure)
+	}
+	if !strings.Contains(stderr.String(), "display 0: permissão negada") {
+		t.Fatalf("stderr deveria citar o display: %q", stderr.String())
+	}
+	if _, err := os.Stat(out); err == nil {
+		t.Fatalf("captura incompleta não deveria ser gravada")
+	}
+}
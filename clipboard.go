@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// Clipboard publica imagens (já codificadas em PNG) na área de transferência.
+type Clipboard interface {
+	SetImage(pngData []byte) error
+}
+
+var errClipboardUnavailable = errors.New("área de transferência não suportada neste sistema")
+
+// newClipboard retorna a implementação da plataforma (ver clipboard_x11.go e
+// clipboard_other.go).
+func newClipboard() Clipboard {
+	return platformClipboard()
+}
+
+// fallbackClipboard tenta cada implementação em ordem até uma funcionar.
+type fallbackClipboard []Clipboard
+
+func (f fallbackClipboard) SetImage(data []byte) error {
+	if len(f) == 0 {
+		return errClipboardUnavailable
+	}
+	var errs []error
+	for _, c := range f {
+		err := c.SetImage(data)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// clipboardKeeper é implementado pelos clipboards cujo conteúdo some com o
+// processo; keep tenta deixá-lo com outro programa antes de sair.
+type clipboardKeeper interface {
+	keep(timeout time.Duration) error
+}
+
+// keep repassa o pedido aos membros da cadeia que precisam dele.
+func (f fallbackClipboard) keep(timeout time.Duration) error {
+	var errs []error
+	for _, c := range f {
+		if k, ok := c.(clipboardKeeper); ok {
+			errs = append(errs, k.keep(timeout))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// commandClipboard usa uma ferramenta externa que lê a imagem do stdin
+// (wl-copy, xclip). Ambas continuam em segundo plano servindo o conteúdo.
+type commandClipboard struct {
+	name string
+	args []string
+}
+
+func (c commandClipboard) SetImage(data []byte) error {
+	path, err := exec.LookPath(c.name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.name, err)
+	}
+	// stdout/stderr não são capturados: o processo que fica em segundo
+	// plano herdaria o pipe e Run só voltaria quando a seleção mudasse
+	cmd := exec.Command(path, c.args...)
+	cmd.Stdin = bytes.NewReader(data)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", c.name, err)
+	}
+	return nil
+}
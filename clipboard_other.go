@@ -0,0 +1,7 @@
+//go:build !linux && !freebsd && !netbsd && !openbsd
+
+package main
+
+func platformClipboard() Clipboard {
+	return fallbackClipboard{}
+}
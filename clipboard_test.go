@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// fakeClipboard registra o último conteúdo recebido.
+type fakeClipboard struct {
+	data  []byte
+	calls int
+	err   error
+}
+
+func (f *fakeClipboard) SetImage(data []byte) error {
+	f.calls++
+	if f.err != nil {
+		return f.err
+	}
+	f.data = append([]byte(nil), data...)
+	return nil
+}
+
+func TestDoCopySendsPNG(t *testing.T) {
+	raw := sampleImage(40, 30, false)
+	clip := &fakeClipboard{}
+	app := &App{rawBG: raw, clipboard: clip}
+	app.selX0, app.selY0, app.selX1, app.selY1 = 5, 6, 25, 16
+	app.hasSelection = true
+
+	app.doCopy()
+	if clip.calls != 1 {
+		t.Fatalf("SetImage chamado %d vezes", clip.calls)
+	}
+	got, err := png.Decode(bytes.NewReader(clip.data))
+	if err != nil {
+		t.Fatalf("conteúdo não é PNG: %v", err)
+	}
+	sameNRGBA(t, raw.SubImage(image.Rect(5, 6, 25, 16)), got)
+	if !app.hasSelection {
+		t.Fatalf("seleção deveria ser mantida após copiar")
+	}
+	if !strings.Contains(app.infoMessage, "copiada") {
+		t.Fatalf("mensagem inesperada: %q", app.infoMessage)
+	}
+}
+
+func TestDoCopyReportsError(t *testing.T) {
+	clip := &fakeClipboard{err: errors.New("sem servidor")}
+	app := &App{rawBG: sampleImage(10, 10, false), clipboard: clip}
+	app.selX0, app.selY0, app.selX1, app.selY1 = 0, 0, 5, 5
+	app.hasSelection = true
+
+	app.doCopy()
+	if !strings.Contains(app.infoMessage, "sem servidor") {
+		t.Fatalf("erro não reportado: %q", app.infoMessage)
+	}
+}
+
+func TestDoCopyWithoutSelection(t *testing.T) {
+	clip := &fakeClipboard{}
+	app := &App{rawBG: sampleImage(10, 10, false), clipboard: clip}
+	app.doCopy()
+	if clip.calls != 0 {
+		t.Fatalf("não deveria copiar sem seleção")
+	}
+}
+
+func TestFallbackClipboard(t *testing.T) {
+	broken := &fakeClipboard{err: errors.New("x11 indisponível")}
+	ok := &fakeClipboard{}
+	unused := &fakeClipboard{}
+	if err := (fallbackClipboard{broken, ok, unused}).SetImage([]byte("png")); err != nil {
+		t.Fatalf("SetImage: %v", err)
+	}
+	if broken.calls != 1 || ok.calls != 1 || unused.calls != 0 {
+		t.Fatalf("ordem de tentativas inesperada: %d %d %d", broken.calls, ok.calls, unused.calls)
+	}
+	if string(ok.data) != "png" {
+		t.Fatalf("dados: %q", ok.data)
+	}
+
+	err := (fallbackClipboard{broken}).SetImage(nil)
+	if err == nil || !strings.Contains(err.Error(), "x11 indisponível") {
+		t.Fatalf("erro esperado, got %v", err)
+	}
+	if err := (fallbackClipboard{}).SetImage(nil); !errors.Is(err, errClipboardUnavailable) {
+		t.Fatalf("lista vazia: got %v", err)
+	}
+}
+
+func TestLayoutCopyButton(t *testing.T) {
+	app := &App{}
+	app.layoutButtons(800, 600)
+	rectEq(t, app.copyBtn.Rect, image.Rect(16+2*128, 600-16-32, 16+2*128+120, 600-16))
+	if app.copyBtn.Rect.Overlaps(app.cancelBtn.Rect) {
+		t.Fatalf("botão copiar sobrepõe cancelar")
+	}
+}
@@ -0,0 +1,283 @@
+//go:build linux || freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+func platformClipboard() Clipboard {
+	return clipboardChain(os.Getenv("DISPLAY"), os.Getenv("WAYLAND_DISPLAY"))
+}
+
+// clipboardChain monta a ordem de tentativas: o dono da seleção X11 dentro
+// do processo primeiro (sem depender de ferramentas instaladas; ao sair, keep
+// entrega a imagem ao gerenciador de área de transferência), wl-copy e xclip
+// só como alternativa quando ele falha. Em sessões XWayland DISPLAY também
+// está definido e a seleção X11 chega aos apps Wayland pela ponte do
+// XWayland.
+func clipboardChain(display, wayland string) fallbackClipboard {
+	var chain fallbackClipboard
+	if display != "" {
+		chain = append(chain, &x11Clipboard{})
+	}
+	if wayland != "" {
+		chain = append(chain, commandClipboard{name: "wl-copy", args: []string{"--type", "image/png"}})
+	}
+	if display != "" {
+		chain = append(chain, commandClipboard{name: "xclip", args: []string{"-selection", "clipboard", "-t", "image/png", "-i"}})
+	}
+	return chain
+}
+
+// x11Clipboard assume a seleção CLIPBOARD e serve image/png enquanto o app
+// estiver aberto (ou até outro programa assumir a seleção). Ao sair, keep
+// entrega o conteúdo ao gerenciador de área de transferência, se houver.
+type x11Clipboard struct {
+	mu    sync.Mutex
+	owner *x11SelectionOwner
+}
+
+func (c *x11Clipboard) SetImage(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.owner != nil {
+		c.owner.close()
+		c.owner = nil
+	}
+	o, err := newX11SelectionOwner(data)
+	if err != nil {
+		return fmt.Errorf("x11: %w", err)
+	}
+	c.owner = o
+	return nil
+}
+
+var errNoClipboardManager = errors.New("nenhum gerenciador de área de transferência; a imagem copiada some ao fechar o app")
+
+// keep pede ao gerenciador de área de transferência (protocolo SAVE_TARGETS
+// do ICCCM) que guarde uma cópia do conteúdo antes de o processo sair.
+func (c *x11Clipboard) keep(timeout time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.owner == nil {
+		return nil
+	}
+	return c.owner.handOff(timeout)
+}
+
+// acima disso a transferência usa o protocolo INCR (requisições X11 comuns
+// são limitadas a 256 KiB)
+const x11ChunkSize = 64 * 1024
+
+type x11Atoms struct {
+	clipboard, targets, png, incr xproto.Atom
+	manager, saveTargets          xproto.Atom
+}
+
+type x11Transfer struct {
+	window   xproto.Window
+	property xproto.Atom
+	offset   int
+}
+
+type x11SelectionOwner struct {
+	conn      *xgb.Conn
+	win       xproto.Window
+	atoms     x11Atoms
+	data      []byte
+	transfers map[[2]uint32]*x11Transfer
+	closeOnce sync.Once
+	done      chan struct{} // fechado quando serve termina
+	saved     chan struct{} // fechado quando o gerenciador confirma o SAVE_TARGETS
+	savedOnce sync.Once
+}
+
+func newX11SelectionOwner(data []byte) (*x11SelectionOwner, error) {
+	conn, err := xgb.NewConn()
+	if err != nil {
+		return nil, err
+	}
+	o := &x11SelectionOwner{
+		conn:      conn,
+		data:      data,
+		transfers: map[[2]uint32]*x11Transfer{},
+		done:      make(chan struct{}),
+		saved:     make(chan struct{}),
+	}
+	if err := o.init(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go o.serve()
+	return o, nil
+}
+
+func (o *x11SelectionOwner) init() error {
+	var err error
+	for name, dst := range map[string]*xproto.Atom{
+		"CLIPBOARD": &o.atoms.clipboard,
+		"TARGETS":   &o.atoms.targets,
+		"image/png": &o.atoms.png,
+		"INCR":      &o.atoms.incr,
+
+		"CLIPBOARD_MANAGER": &o.atoms.manager,
+		"SAVE_TARGETS":      &o.atoms.saveTargets,
+	} {
+		if *dst, err = internAtom(o.conn, name); err != nil {
+			return err
+		}
+	}
+
+	root := xproto.Setup(o.conn).DefaultScreen(o.conn).Root
+	wid, err := xproto.NewWindowId(o.conn)
+	if err != nil {
+		return err
+	}
+	o.win = wid
+	err = xproto.CreateWindowChecked(o.conn, 0, wid, root, 0, 0, 1, 1, 0,
+		xproto.WindowClassInputOnly, 0, 0, nil).Check()
+	if err != nil {
+		return err
+	}
+	if err := xproto.SetSelectionOwnerChecked(o.conn, wid, o.atoms.clipboard, xproto.TimeCurrentTime).Check(); err != nil {
+		return err
+	}
+	reply, err := xproto.GetSelectionOwner(o.conn, o.atoms.clipboard).Reply()
+	if err != nil {
+		return err
+	}
+	if reply.Owner != wid {
+		return errors.New("não foi possível assumir a seleção CLIPBOARD")
+	}
+	return nil
+}
+
+func internAtom(conn *xgb.Conn, name string) (xproto.Atom, error) {
+	reply, err := xproto.InternAtom(conn, false, uint16(len(name)), name).Reply()
+	if err != nil {
+		return 0, err
+	}
+	return reply.Atom, nil
+}
+
+func (o *x11SelectionOwner) close() {
+	o.closeOnce.Do(o.conn.Close)
+}
+
+func (o *x11SelectionOwner) serve() {
+	defer close(o.done)
+	defer o.close()
+	for {
+		ev, err := o.conn.WaitForEvent()
+		if ev == nil && err == nil {
+			return // conexão encerrada
+		}
+		switch e := ev.(type) {
+		case xproto.SelectionRequestEvent:
+			o.handleRequest(e)
+		case xproto.PropertyNotifyEvent:
+			o.continueTransfer(e)
+		case xproto.SelectionNotifyEvent:
+			if e.Selection == o.atoms.manager && e.Target == o.atoms.saveTargets {
+				o.savedOnce.Do(func() { close(o.saved) })
+			}
+		case xproto.SelectionClearEvent:
+			return // outro programa assumiu a área de transferência
+		}
+	}
+}
+
+// handOff entrega o conteúdo ao dono de CLIPBOARD_MANAGER e espera a
+// confirmação (ou o gerenciador assumir a seleção). Enquanto isso serve
+// continua atendendo os pedidos de dados dele.
+func (o *x11SelectionOwner) handOff(timeout time.Duration) error {
+	select {
+	case <-o.done:
+		return nil // outro programa já assumiu a seleção
+	default:
+	}
+	reply, err := xproto.GetSelectionOwner(o.conn, o.atoms.manager).Reply()
+	if err != nil {
+		return err
+	}
+	if reply.Owner == xproto.WindowNone {
+		return errNoClipboardManager
+	}
+	// propriedade None: o gerenciador guarda todos os targets
+	err = xproto.ConvertSelectionChecked(o.conn, o.win, o.atoms.manager, o.atoms.saveTargets,
+		xproto.AtomNone, xproto.TimeCurrentTime).Check()
+	if err != nil {
+		return err
+	}
+	select {
+	case <-o.saved:
+	case <-o.done:
+	case <-time.After(timeout):
+		return errors.New("o gerenciador de área de transferência não respondeu")
+	}
+	return nil
+}
+
+func (o *x11SelectionOwner) handleRequest(e xproto.SelectionRequestEvent) {
+	prop := e.Property
+	if prop == xproto.AtomNone {
+		prop = e.Target // clientes antigos (ICCCM)
+	}
+	notify := xproto.SelectionNotifyEvent{
+		Time:      e.Time,
+		Requestor: e.Requestor,
+		Selection: e.Selection,
+		Target:    e.Target,
+		Property:  prop,
+	}
+
+	switch e.Target {
+	case o.atoms.targets:
+		atoms := make([]byte, 8)
+		binary.LittleEndian.PutUint32(atoms[0:], uint32(o.atoms.targets))
+		binary.LittleEndian.PutUint32(atoms[4:], uint32(o.atoms.png))
+		xproto.ChangeProperty(o.conn, xproto.PropModeReplace, e.Requestor, prop, xproto.AtomAtom, 32, 2, atoms)
+	case o.atoms.png:
+		if len(o.data) <= x11ChunkSize {
+			xproto.ChangeProperty(o.conn, xproto.PropModeReplace, e.Requestor, prop, o.atoms.png, 8, uint32(len(o.data)), o.data)
+			break
+		}
+		// INCR: anuncia o tamanho e envia os blocos a cada PropertyNotify(Delete)
+		xproto.ChangeWindowAttributes(o.conn, e.Requestor, xproto.CwEventMask, []uint32{xproto.EventMaskPropertyChange})
+		size := make([]byte, 4)
+		binary.LittleEndian.PutUint32(size, uint32(len(o.data)))
+		xproto.ChangeProperty(o.conn, xproto.PropModeReplace, e.Requestor, prop, o.atoms.incr, 32, 1, size)
+		o.transfers[[2]uint32{uint32(e.Requestor), uint32(prop)}] = &x11Transfer{window: e.Requestor, property: prop}
+	default:
+		notify.Property = xproto.AtomNone
+	}
+	xproto.SendEvent(o.conn, false, e.Requestor, 0, string(notify.Bytes()))
+}
+
+func (o *x11SelectionOwner) continueTransfer(e xproto.PropertyNotifyEvent) {
+	if e.State != xproto.PropertyDelete {
+		return
+	}
+	key := [2]uint32{uint32(e.Window), uint32(e.Atom)}
+	t, ok := o.transfers[key]
+	if !ok {
+		return
+	}
+	end := min(t.offset+x11ChunkSize, len(o.data))
+	chunk := o.data[t.offset:end]
+	xproto.ChangeProperty(o.conn, xproto.PropModeReplace, t.window, t.property, o.atoms.png, 8, uint32(len(chunk)), chunk)
+	t.offset = end
+	if len(chunk) == 0 {
+		// bloco vazio sinaliza o fim da transferência
+		delete(o.transfers, key)
+	}
+}
@@ -0,0 +1,120 @@
+//go:build linux || freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+// chainNames descreve a cadeia pelo nome de cada implementação.
+func chainNames(chain fallbackClipboard) []string {
+	var names []string
+	for _, c := range chain {
+		switch c := c.(type) {
+		case commandClipboard:
+			names = append(names, c.name)
+		case *x11Clipboard:
+			names = append(names, "x11")
+		default:
+			names = append(names, "?")
+		}
+	}
+	return names
+}
+
+func TestClipboardChainOrder(t *testing.T) {
+	tests := []struct {
+		name             string
+		display, wayland string
+		want             []string
+	}{
+		// o dono da seleção em processo vem primeiro, inclusive no
+		// XWayland; as ferramentas externas são só alternativa
+		{"xwayland", ":0", "wayland-0", []string{"x11", "wl-copy", "xclip"}},
+		{"x11", ":0", "", []string{"x11", "xclip"}},
+		{"wayland", "", "wayland-0", []string{"wl-copy"}},
+		{"none", "", "", nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := chainNames(clipboardChain(tc.display, tc.wayland))
+			if len(got) != len(tc.want) {
+				t.Fatalf("cadeia = %v; want %v", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Fatalf("cadeia = %v; want %v", got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+// fakeClipboardManager assume CLIPBOARD_MANAGER e confirma cada SAVE_TARGETS,
+// contando os pedidos.
+func fakeClipboardManager(t *testing.T, conn *xgb.Conn) <-chan struct{} {
+	t.Helper()
+	manager, _ := internAtom(conn, "CLIPBOARD_MANAGER")
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	wid, err := xproto.NewWindowId(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = xproto.CreateWindowChecked(conn, 0, wid, root, 0, 0, 1, 1, 0, xproto.WindowClassInputOnly, 0, 0, nil).Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := xproto.SetSelectionOwnerChecked(conn, wid, manager, xproto.TimeCurrentTime).Check(); err != nil {
+		t.Fatal(err)
+	}
+	requests := make(chan struct{}, 1)
+	go func() {
+		for {
+			ev, err := conn.WaitForEvent()
+			if ev == nil && err == nil {
+				return
+			}
+			if e, ok := ev.(xproto.SelectionRequestEvent); ok {
+				notify := xproto.SelectionNotifyEvent{
+					Time: e.Time, Requestor: e.Requestor, Selection: e.Selection, Target: e.Target, Property: e.Property,
+				}
+				xproto.SendEvent(conn, false, e.Requestor, 0, string(notify.Bytes()))
+				requests <- struct{}{}
+			}
+		}
+	}()
+	return requests
+}
+
+func TestX11ClipboardKeepHandsOffToManager(t *testing.T) {
+	display := startXvfb(t)
+	t.Setenv("DISPLAY", display)
+
+	clip := &x11Clipboard{}
+	if err := clip.SetImage([]byte("png")); err != nil {
+		t.Fatalf("SetImage: %v", err)
+	}
+	if err := clip.keep(time.Second); !errors.Is(err, errNoClipboardManager) {
+		t.Fatalf("sem gerenciador: err = %v", err)
+	}
+
+	conn, err := xgb.NewConnDisplay(display)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	requests := fakeClipboardManager(t, conn)
+	if err := clip.keep(2 * time.Second); err != nil {
+		t.Fatalf("keep: %v", err)
+	}
+	select {
+	case <-requests:
+	default:
+		t.Fatalf("o gerenciador não recebeu SAVE_TARGETS")
+	}
+}
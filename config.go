@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Config reúne as preferências persistidas em
+// <UserConfigDir>/go-screentake/config.json. Campos ausentes usam o padrão.
+type Config struct {
+	Format       string   `json:"format"`        // png, jpeg, gif, bmp, tiff
+	Quality      int      `json:"quality"`       // qualidade JPEG (1-100)
+	Dir          string   `json:"dir"`           // diretório de saída ("" = imagens do usuário)
+	NameTemplate string   `json:"name_template"` // ver expandTemplate
+	SizePresets  []string `json:"size_presets"`  // tamanhos fixos da tecla P ("1280x720")
+	Delay        int      `json:"delay"`         // segundos da recaptura com atraso (tecla D)
+}
+
+func defaultConfig() Config {
+	return Config{
+		Format:       "png",
+		Quality:      90,
+		NameTemplate: defaultNameTemplate,
+		SizePresets:  append([]string(nil), defaultSizePresets...),
+		Delay:        defaultDelay,
+	}
+}
+
+// configPath retorna o caminho do arquivo de configuração. GST_CONFIG
+// sobrescreve o local padrão.
+func configPath() string {
+	if p := os.Getenv("GST_CONFIG"); p != "" {
+		return p
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "go-screentake", "config.json")
+}
+
+// loadConfig lê a configuração do disco; arquivo inexistente não é erro.
+func loadConfig(path string) (Config, error) {
+	cfg := defaultConfig()
+	if path == "" {
+		return cfg, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return defaultConfig(), fmt.Errorf("config %s: %w", path, err)
+	}
+	if _, err := lookupFormat(cfg.Format); err != nil {
+		return defaultConfig(), fmt.Errorf("config %s: %w", path, err)
+	}
+	if err := checkQuality(cfg.Quality); err != nil {
+		return defaultConfig(), fmt.Errorf("config %s: %w", path, err)
+	}
+	if cfg.Delay < 0 {
+		return defaultConfig(), fmt.Errorf("config %s: delay negativo (%d)", path, cfg.Delay)
+	}
+	for _, s := range cfg.SizePresets {
+		if _, _, err := parseSize(s); err != nil {
+			return defaultConfig(), fmt.Errorf("config %s: %w", path, err)
+		}
+	}
+	return cfg, nil
+}
+
+func (c Config) encodeOptions() encodeOptions {
+	return encodeOptions{Quality: c.Quality}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	cfg, err := loadConfig(filepath.Join(dir, "missing.json"))
+	if err != nil || !reflect.DeepEqual(cfg, defaultConfig()) {
+		t.Fatalf("arquivo ausente deveria usar o padrão: %+v, %v", cfg, err)
+	}
+
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"format":"jpg","quality":75}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = loadConfig(path)
+	if err != nil || cfg.Format != "jpg" || cfg.Quality != 75 {
+		t.Fatalf("config inesperada: %+v, %v", cfg, err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"format":"xcf"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = loadConfig(path); err == nil || !reflect.DeepEqual(cfg, defaultConfig()) {
+		t.Fatalf("formato inválido deveria falhar e voltar ao padrão: %+v, %v", cfg, err)
+	}
+}
+
+func TestLoadConfigSizePresets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"size_presets":["1200x630"," 800 x 418 "]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadConfig(path)
+	if err != nil || !reflect.DeepEqual(cfg.SizePresets, []string{"1200x630", " 800 x 418 "}) {
+		t.Fatalf("size_presets: %+v, %v", cfg.SizePresets, err)
+	}
+	if got := loadSizePresets(cfg.SizePresets); len(got) != 2 || got[1] != (selectionConstraint{FixedW: 800, FixedH: 418}) {
+		t.Fatalf("presets: %+v", got)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"size_presets":["grande"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig(path); err == nil {
+		t.Fatalf("tamanho inválido deveria falhar")
+	}
+}
+
+func TestLoadConfigRejectsNegativeDelay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"delay":-2}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err := loadConfig(path); err == nil || cfg.Delay != defaultDelay {
+		t.Fatalf("delay negativo deveria falhar: %+v, %v", cfg, err)
+	}
+}
+
+func TestLoadConfigRejectsBadQuality(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	for _, q := range []string{"0", "-5", "101"} {
+		if err := os.WriteFile(path, []byte(`{"quality":`+q+`}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if cfg, err := loadConfig(path); err == nil || cfg.Quality != defaultConfig().Quality {
+			t.Fatalf("quality %s deveria falhar: %+v, %v", q, cfg, err)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// defaultDelay é o atraso padrão da tecla D, em segundos.
+const defaultDelay = 3
+
+const readyMessage = "Arraste para selecionar. Solte para ver opções. Enter=Salvar | Esc=Cancelar | Q/E trocar monitor | A 'todos' | F formato | Ctrl+C copiar | 1-8/T anotar | D recapturar"
+
+// countdownLeft devolve os segundos inteiros que faltam até end
+// (arredondando para cima), ou 0 quando o prazo passou.
+func countdownLeft(now, end time.Time) int {
+	d := end.Sub(now)
+	if d <= 0 {
+		return 0
+	}
+	return int((d + time.Second - 1) / time.Second)
+}
+
+func (a *App) counting() bool {
+	return !a.delayEnd.IsZero()
+}
+
+// pipeline devolve o pipeline de captura do app, criando-o na primeira vez.
+func (a *App) pipeline() *capturePipeline {
+	if a.captures == nil {
+		a.captures = newCapturePipeline()
+	}
+	return a.captures
+}
+
+// capturing informa se há uma captura em curso (o resultado ainda não
+// chegou).
+func (a *App) capturing() bool {
+	return a.captures != nil && a.captures.busy()
+}
+
+// startCapture pede ao pipeline a captura do modo atual (monitor ou desktop
+// inteiro); o resultado chega em Update via receiveCapture.
+func (a *App) startCapture() {
+	a.infoMessage = "Capturando tela..."
+	a.captureSince = time.Now()
+	if a.restoreWindow {
+		ebiten.SetWindowTitle(capturingTitle)
+	}
+	c, all, disp, lister := a.capturer, a.modeAll, a.curDisp, a.windowLister
+	a.pipeline().request(func(ctx context.Context) captureResult {
+		img, err := captureMode(ctx, c, all, disp)
+		r := captureResult{img: img, err: err}
+		if img != nil && lister != nil {
+			// logo depois da captura, com a janela do app ainda fora da
+			// tela: é esta lista que a escolha de janela usa
+			r.windows, r.windowsErr = lister.Windows()
+		}
+		return r
+	})
+}
+
+// pollCapture entrega ao app o resultado da captura mais recente, se chegou.
+func (a *App) pollCapture() {
+	if a.captures == nil {
+		return
+	}
+	r, ok := a.captures.poll()
+	if !ok {
+		return
+	}
+	if r.img == nil {
+		// sem imagem não há o que mostrar: tela de erro com a opção de
+		// tentar de novo, nunca um fundo em branco que dê para salvar
+		a.showWindow()
+		a.captureErr = r.err
+		if a.captureErr == nil {
+			a.captureErr = &CaptureError{Kind: errCaptureFailed, Display: -1}
+		}
+		return
+	}
+	a.windows, a.windowsErr = r.windows, r.windowsErr
+	a.receiveCapture(r.img)
+	if r.err != nil {
+		// captura parcial: mostra o que veio e diz quais displays falharam
+		a.infoMessage = "Captura incompleta: " + strings.ReplaceAll(r.err.Error(), "\n", "; ")
+	}
+}
+
+// capturingTitle fica no título (visível na barra de tarefas com a janela
+// minimizada) enquanto a captura está em curso.
+const capturingTitle = "Snip - capturando… (Esc cancela)"
+
+// captureRevealAfter é quanto uma captura pode demorar com a janela
+// minimizada; passado isso (backend travado), a janela volta para mostrar o
+// "capturando…" e aceitar o Esc.
+const captureRevealAfter = 2 * time.Second
+
+// revealSlowCapture restaura a janela quando a captura em curso passou de
+// captureRevealAfter. restoreWindow continua ligado: o título volta ao
+// normal quando o resultado chegar.
+func (a *App) revealSlowCapture(now time.Time) {
+	if !a.restoreWindow || !a.capturing() || now.Sub(a.captureSince) < captureRevealAfter {
+		return
+	}
+	if ebiten.IsWindowMinimized() {
+		ebiten.RestoreWindow()
+	}
+}
+
+// cancelCapture (Esc durante a captura) desiste dela e fica com a imagem
+// atual.
+func (a *App) cancelCapture() {
+	a.pipeline().cancelPending()
+	a.showWindow()
+	a.infoMessage = "Captura cancelada."
+}
+
+// compositorSettle é a espera entre minimizar a janela e capturar, para o
+// compositor redesenhar o que estava atrás dela.
+const compositorSettle = 300 * time.Millisecond
+
+// startDelayedCapture agenda a recaptura para daqui a secs segundos, para
+// dar tempo de abrir menus e tooltips. Sem atraso, captura na hora (a janela
+// ainda não apareceu).
+func (a *App) startDelayedCapture(secs int, now time.Time) {
+	if secs <= 0 && !a.capturing() && !a.counting() {
+		a.startCapture()
+		return
+	}
+	a.scheduleCapture(time.Duration(secs)*time.Second, now)
+	a.infoMessage = fmt.Sprintf("Capturando em %d s…", secs)
+}
+
+// recapture refaz a captura do modo atual sem a janela do app na imagem
+// (troca de monitor, A, F5).
+func (a *App) recapture(now time.Time) {
+	a.scheduleCapture(compositorSettle, now)
+}
+
+// scheduleCapture minimiza a janela e captura depois de wait. Durante a
+// espera não há o que fazer: a captura usa o monitor/modo do momento em que
+// dispara. Uma captura já em curso é cancelada, para a imagem corresponder
+// à última escolha.
+func (a *App) scheduleCapture(wait time.Duration, now time.Time) {
+	if a.counting() {
+		return
+	}
+	a.pipeline().cancelPending()
+	a.finishTextEdit()
+	a.delayEnd = now.Add(max(wait, compositorSettle))
+	a.delayShown = 0
+	a.restoreWindow = true
+	a.infoMessage = "Capturando tela..."
+	ebiten.MinimizeWindow()
+	a.updateDelayedCapture(now)
+}
+
+// updateDelayedCapture mostra a contagem no título da janela (visível na
+// barra de tarefas) e dispara a captura quando o prazo termina.
+func (a *App) updateDelayedCapture(now time.Time) {
+	if !a.counting() {
+		return
+	}
+	if left := countdownLeft(now, a.delayEnd); left > 0 {
+		if left != a.delayShown {
+			a.delayShown = left
+			ebiten.SetWindowTitle(fmt.Sprintf("Snip - capturando em %d…", left))
+		}
+		return
+	}
+	a.delayEnd = time.Time{}
+	a.startCapture()
+}
+
+// cancelDelayedCapture (Esc durante a contagem) volta sem capturar.
+func (a *App) cancelDelayedCapture() {
+	a.delayEnd = time.Time{}
+	a.showWindow()
+	a.infoMessage = "Captura com atraso cancelada."
+}
+
+// receiveCapture troca o fundo pela captura nova. A seleção sobrevive se o
+// tamanho da imagem não mudou (recapturar o mesmo monitor com um menu
+// aberto, por exemplo).
+func (a *App) receiveCapture(raw *image.RGBA) {
+	a.showWindow()
+	if raw == nil {
+		return
+	}
+	if a.rawBG == nil || raw.Bounds() != a.rawBG.Bounds() {
+		a.clearSelection()
+		a.history = history{}
+	}
+	a.rawBG = raw
+	a.bg = ebiten.NewImageFromImage(raw)
+	a.buildEdges()
+	a.captured = true
+	a.captureErr = nil
+	a.annoDirty = true
+	a.layoutButtons(raw.Bounds().Dx(), raw.Bounds().Dy())
+	a.infoMessage = readyMessage
+	a.dropOversizedPreset()
+	if a.hotplug.notice != "" {
+		a.infoMessage = a.hotplug.notice
+		a.hotplug.notice = ""
+	}
+}
+
+// showWindow restaura a janela minimizada para a captura.
+func (a *App) showWindow() {
+	if !a.restoreWindow {
+		return
+	}
+	a.restoreWindow = false
+	if ebiten.IsWindowMinimized() {
+		ebiten.RestoreWindow()
+	}
+	ebiten.SetWindowTitle(a.windowTitle())
+}
+
+func (a *App) windowTitle() string {
+	if a.modeAll {
+		return "Snip - Seleção de área (todos monitores)"
+	}
+	return "Snip - Seleção de área (1 monitor)"
+}
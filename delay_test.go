@@ -0,0 +1,159 @@
+package main
+
+import (
+	"image"
+	"strings"
+	"testing"
+	"time"
+)
+
+// finishCapture encerra a espera agendada e entrega o resultado ao app.
+func finishCapture(t *testing.T, app *App) {
+	t.Helper()
+	app.updateDelayedCapture(app.delayEnd)
+	waitCapture(t, app)
+}
+
+// waitCapture roda o poll do loop do jogo até a captura chegar.
+func waitCapture(t *testing.T, app *App) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for app.capturing() {
+		if time.Now().After(deadline) {
+			t.Fatal("captura não chegou")
+		}
+		app.pollCapture()
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCountdownLeft(t *testing.T) {
+	t0 := time.Unix(1000, 0)
+	end := t0.Add(3 * time.Second)
+	for _, tc := range []struct {
+		now  time.Time
+		want int
+	}{
+		{t0, 3},
+		{t0.Add(500 * time.Millisecond), 3},
+		{t0.Add(2 * time.Second), 1},
+		{t0.Add(2999 * time.Millisecond), 1},
+		{end, 0},
+		{end.Add(time.Second), 0},
+	} {
+		if got := countdownLeft(tc.now, end); got != tc.want {
+			t.Fatalf("countdownLeft(%v) = %d, want %d", tc.now.Sub(t0), got, tc.want)
+		}
+	}
+}
+
+func TestDelayedCaptureKeepsSelection(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	app := &App{
+		capturer: newFakeCapturer(desktop, displays...),
+		displays: displays,
+		curDisp:  1,
+		rawBG:    blankCanvas(60, 40),
+	}
+	app.setSelection(image.Rect(5, 5, 30, 30))
+
+	t0 := time.Unix(1000, 0)
+	app.startDelayedCapture(2, t0)
+	if !app.counting() || app.capturing() {
+		t.Fatalf("deveria contar antes de capturar")
+	}
+	app.startDelayedCapture(2, t0) // repetir a tecla não reinicia a contagem
+	app.updateDelayedCapture(t0.Add(1500 * time.Millisecond))
+	if app.capturing() {
+		t.Fatalf("capturou antes do prazo")
+	}
+	app.updateDelayedCapture(t0.Add(2 * time.Second))
+	if app.counting() || !app.capturing() {
+		t.Fatalf("deveria capturar no fim da contagem")
+	}
+
+	finishCapture(t, app)
+	if app.capturing() || !app.hasSelection {
+		t.Fatalf("mesmo tamanho: a seleção deveria continuar")
+	}
+	rectEq(t, app.selectionRect(), image.Rect(5, 5, 30, 30))
+	if c := app.rawBG.RGBAAt(0, 0); c.B != 255 {
+		t.Fatalf("fundo deveria vir do display 1 (azul): %v", c)
+	}
+}
+
+func TestReceiveCaptureResetsOnSizeChange(t *testing.T) {
+	app := &App{rawBG: blankCanvas(60, 40)}
+	app.setSelection(image.Rect(5, 5, 30, 30))
+	app.record(selectionCmd{to: app.selectionRect()})
+	app.receiveCapture(blankCanvas(100, 40))
+	if app.hasSelection || len(app.history.done) != 0 {
+		t.Fatalf("imagem de outro tamanho deveria limpar seleção e histórico")
+	}
+}
+
+func TestCancelDelayedCapture(t *testing.T) {
+	app := &App{rawBG: blankCanvas(60, 40)}
+	app.startDelayedCapture(5, time.Now())
+	app.cancelDelayedCapture()
+	if app.counting() || app.capturing() {
+		t.Fatalf("cancelar deveria encerrar a contagem sem capturar")
+	}
+}
+
+func TestRecaptureDuringCaptureRetakesLatest(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	slow := &slowCapturer{fakeCapturer: newFakeCapturer(desktop, displays...), release: make(chan struct{})}
+	app := &App{
+		capturer: slow,
+		displays: displays,
+		rawBG:    blankCanvas(60, 40),
+	}
+	app.switchDisplay(1)
+	app.updateDelayedCapture(app.delayEnd) // captura do display 1 travada no backend
+	app.switchDisplay(0)                   // troca de novo antes de chegar
+	if !app.counting() {
+		t.Fatalf("a segunda troca deveria agendar outra captura")
+	}
+	close(slow.release)
+	finishCapture(t, app)
+	rectEq(t, app.rawBG.Bounds(), image.Rect(0, 0, 40, 30))
+	if c := app.rawBG.RGBAAt(0, 0); c.R != 255 {
+		t.Fatalf("fundo deveria vir do display 0 (vermelho): %v", c)
+	}
+}
+
+func TestPartialCaptureKeepsImageAndWarns(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	c := &barrierCapturer{fakeCapturer: newFakeCapturer(desktop, displays...), n: 2, all: make(chan struct{}), fail: displays[0]}
+	app := &App{capturer: c, displays: displays, modeAll: true, rawBG: blankCanvas(60, 40)}
+	app.startCapture()
+	waitCapture(t, app)
+	rectEq(t, app.rawBG.Bounds(), image.Rect(0, 0, 100, 40))
+	if !strings.Contains(app.infoMessage, "display 0") {
+		t.Fatalf("mensagem deveria citar o display que falhou: %q", app.infoMessage)
+	}
+}
+
+func TestCancelCaptureKeepsImage(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	slow := &slowCapturer{fakeCapturer: newFakeCapturer(desktop, displays...), release: make(chan struct{})}
+	bg := blankCanvas(60, 40)
+	app := &App{capturer: slow, displays: displays, rawBG: bg, captured: true}
+
+	app.recapture(time.Now())
+	app.updateDelayedCapture(app.delayEnd)
+	if !app.capturing() || !app.restoreWindow {
+		t.Fatalf("deveria estar capturando com a janela minimizada")
+	}
+	app.cancelCapture()
+	if app.capturing() || app.restoreWindow {
+		t.Fatalf("cancelar deveria encerrar a captura e devolver a janela")
+	}
+	close(slow.release)
+	time.Sleep(10 * time.Millisecond)
+	app.pollCapture()
+	if app.rawBG != bg {
+		t.Fatalf("captura cancelada não pode trocar o fundo")
+	}
+}
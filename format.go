@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+)
+
+type encodeOptions struct {
+	Quality int // JPEG: 1-100 (0 = padrão)
+}
+
+// checkQuality valida a qualidade JPEG vinda da flag ou da configuração.
+func checkQuality(q int) error {
+	if q < 1 || q > 100 {
+		return fmt.Errorf("qualidade fora de 1-100: %d", q)
+	}
+	return nil
+}
+
+// outputFormat descreve um formato de saída registrado.
+type outputFormat struct {
+	Name   string
+	Ext    string
+	Encode func(w io.Writer, img image.Image, opts encodeOptions) error
+}
+
+// Label é o nome exibido na UI e nas mensagens.
+func (f outputFormat) Label() string {
+	return strings.ToUpper(f.Name)
+}
+
+// outputFormats é o registro de encoders, na ordem usada pelo atalho F.
+var outputFormats = []outputFormat{
+	{Name: "png", Ext: ".png", Encode: func(w io.Writer, img image.Image, _ encodeOptions) error {
+		return png.Encode(w, img)
+	}},
+	{Name: "jpeg", Ext: ".jpg", Encode: func(w io.Writer, img image.Image, opts encodeOptions) error {
+		q := opts.Quality
+		if q <= 0 {
+			q = defaultConfig().Quality
+		}
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: q})
+	}},
+	{Name: "gif", Ext: ".gif", Encode: func(w io.Writer, img image.Image, _ encodeOptions) error {
+		return gif.Encode(w, img, &gif.Options{NumColors: 256, Quantizer: medianCutQuantizer{}})
+	}},
+	{Name: "bmp", Ext: ".bmp", Encode: func(w io.Writer, img image.Image, _ encodeOptions) error {
+		return bmp.Encode(w, img)
+	}},
+	{Name: "tiff", Ext: ".tiff", Encode: func(w io.Writer, img image.Image, _ encodeOptions) error {
+		return tiff.Encode(w, img, &tiff.Options{Compression: tiff.Deflate})
+	}},
+}
+
+var formatAliases = map[string]string{"jpg": "jpeg", "tif": "tiff"}
+
+// lookupFormat encontra um formato pelo nome (ou alias). Vazio = PNG.
+func lookupFormat(name string) (outputFormat, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return outputFormats[0], nil
+	}
+	if alias, ok := formatAliases[name]; ok {
+		name = alias
+	}
+	for _, f := range outputFormats {
+		if f.Name == name {
+			return f, nil
+		}
+	}
+	return outputFormat{}, fmt.Errorf("formato desconhecido: %q", name)
+}
+
+// formatForPath deduz o formato pela extensão do arquivo.
+func formatForPath(path string) (outputFormat, bool) {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	if ext == "" {
+		return outputFormat{}, false
+	}
+	f, err := lookupFormat(ext)
+	return f, err == nil
+}
+
+// nextFormat devolve o formato seguinte ao informado (cíclico).
+func nextFormat(name string) outputFormat {
+	cur, _ := lookupFormat(name)
+	for i, f := range outputFormats {
+		if f.Name == cur.Name {
+			return outputFormats[(i+1)%len(outputFormats)]
+		}
+	}
+	return outputFormats[0]
+}
+
+// encodeImage codifica a imagem no formato de saída. Compartilhado entre a
+// GUI e o modo CLI.
+func encodeImage(img image.Image, f outputFormat, opts encodeOptions) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := f.Encode(&buf, img, opts); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ---- quantização para GIF ----
+
+// medianCutQuantizer gera uma paleta adaptativa por median cut sobre um
+// histograma de 15 bits (5 por canal), bem melhor que a Plan9 padrão para
+// capturas de interface.
+type medianCutQuantizer struct{}
+
+type colorBucket struct {
+	r, g, b uint8 // cor reduzida a 5 bits por canal
+	count   int
+}
+
+func (medianCutQuantizer) Quantize(p color.Palette, m image.Image) color.Palette {
+	limit := cap(p) - len(p)
+	if limit <= 0 {
+		return p
+	}
+	var hist [1 << 15]int
+	b := m.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := m.At(x, y).RGBA()
+			hist[(r>>11)<<10|(g>>11)<<5|bl>>11]++
+		}
+	}
+	var buckets []colorBucket
+	for i, n := range hist {
+		if n > 0 {
+			buckets = append(buckets, colorBucket{r: uint8(i >> 10), g: uint8(i >> 5 & 31), b: uint8(i & 31), count: n})
+		}
+	}
+	if len(buckets) == 0 {
+		return p
+	}
+
+	boxes := [][]colorBucket{buckets}
+	for len(boxes) < limit {
+		// divide a caixa mais populosa que ainda tenha mais de uma cor
+		best := -1
+		for i, box := range boxes {
+			if len(box) > 1 && (best < 0 || boxPopulation(box) > boxPopulation(boxes[best])) {
+				best = i
+			}
+		}
+		if best < 0 {
+			break
+		}
+		lo, hi := splitBox(boxes[best])
+		boxes[best] = lo
+		boxes = append(boxes, hi)
+	}
+
+	for _, box := range boxes {
+		var sr, sg, sb, n int
+		for _, c := range box {
+			sr += int(c.r) * c.count
+			sg += int(c.g) * c.count
+			sb += int(c.b) * c.count
+			n += c.count
+		}
+		p = append(p, color.RGBA{
+			R: uint8((sr*255/31 + n/2) / n),
+			G: uint8((sg*255/31 + n/2) / n),
+			B: uint8((sb*255/31 + n/2) / n),
+			A: 255,
+		})
+	}
+	return p
+}
+
+func boxPopulation(box []colorBucket) int {
+	n := 0
+	for _, c := range box {
+		n += c.count
+	}
+	return n
+}
+
+// splitBox corta a caixa na mediana (ponderada) do canal de maior amplitude.
+func splitBox(box []colorBucket) ([]colorBucket, []colorBucket) {
+	var lo, hi [3]uint8
+	lo = [3]uint8{31, 31, 31}
+	for _, c := range box {
+		for i, v := range [3]uint8{c.r, c.g, c.b} {
+			lo[i] = min8(lo[i], v)
+			hi[i] = max(hi[i], v)
+		}
+	}
+	axis := 0
+	for i := 1; i < 3; i++ {
+		if hi[i]-lo[i] > hi[axis]-lo[axis] {
+			axis = i
+		}
+	}
+	channel := func(c colorBucket) uint8 { return [3]uint8{c.r, c.g, c.b}[axis] }
+	sort.Slice(box, func(i, j int) bool { return channel(box[i]) < channel(box[j]) })
+
+	half := boxPopulation(box) / 2
+	acc, cut := 0, 1
+	for i, c := range box[:len(box)-1] {
+		acc += c.count
+		cut = i + 1
+		if acc >= half {
+			break
+		}
+	}
+	return box[:cut:cut], box[cut:]
+}
+
+func min8(a, b uint8) uint8 {
+	if a < b {
+		return a
+	}
+	return b
+}
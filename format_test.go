@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+)
+
+// sampleImage mistura áreas chapadas (cópias para trás), ruído e alfa.
+func sampleImage(w, h int, withAlpha bool) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	rng := rand.New(rand.NewSource(1))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{R: 30, G: 60, B: 90, A: 255}
+			switch {
+			case y < h/3:
+				c = color.RGBA{R: uint8(x * 7), G: uint8(y * 13), B: uint8(x ^ y), A: 255}
+			case y < 2*h/3 && x%5 != 0:
+				c = color.RGBA{R: uint8(rng.Intn(256)), G: uint8(rng.Intn(256)), B: uint8(rng.Intn(256)), A: 255}
+			}
+			if withAlpha && x < w/4 {
+				// RGBA é pré-multiplicado: mantém canais <= alfa
+				c = color.RGBA{R: c.R / 2, G: c.G / 2, B: c.B / 2, A: 128}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func sameNRGBA(t *testing.T, want, got image.Image) {
+	t.Helper()
+	if want.Bounds().Size() != got.Bounds().Size() {
+		t.Fatalf("tamanho: got=%v want=%v", got.Bounds(), want.Bounds())
+	}
+	wb, gb := want.Bounds(), got.Bounds()
+	for y := 0; y < wb.Dy(); y++ {
+		for x := 0; x < wb.Dx(); x++ {
+			w := color.NRGBAModel.Convert(want.At(wb.Min.X+x, wb.Min.Y+y))
+			g := color.NRGBAModel.Convert(got.At(gb.Min.X+x, gb.Min.Y+y))
+			if w != g {
+				t.Fatalf("pixel (%d,%d): got=%v want=%v", x, y, g, w)
+			}
+		}
+	}
+}
+
+func TestLookupFormat(t *testing.T) {
+	tests := map[string]string{"": "png", "PNG": "png", "jpg": "jpeg", "jpeg": "jpeg", "tif": "tiff", "gif": "gif", "bmp": "bmp"}
+	for in, want := range tests {
+		f, err := lookupFormat(in)
+		if err != nil || f.Name != want {
+			t.Fatalf("lookupFormat(%q) = %q, %v; want %q", in, f.Name, err, want)
+		}
+	}
+	if _, err := lookupFormat("xcf"); err == nil {
+		t.Fatalf("esperava erro para formato desconhecido")
+	}
+	if f, ok := formatForPath("/tmp/a.JPG"); !ok || f.Name != "jpeg" {
+		t.Fatalf("formatForPath(.JPG) = %q, %v", f.Name, ok)
+	}
+	if _, ok := formatForPath("-"); ok {
+		t.Fatalf("formatForPath sem extensão deveria falhar")
+	}
+	// o atalho percorre todos os formatos e volta ao início
+	name := "png"
+	for range outputFormats {
+		name = nextFormat(name).Name
+	}
+	if name != "png" {
+		t.Fatalf("ciclo de formatos terminou em %q", name)
+	}
+}
+
+func TestEncodeImageRoundTrip(t *testing.T) {
+	src := sampleImage(40, 30, false)
+	sub := src.SubImage(image.Rect(3, 4, 33, 24))
+
+	lossless := map[string]func(*bytes.Reader) (image.Image, error){
+		"png":  func(r *bytes.Reader) (image.Image, error) { return png.Decode(r) },
+		"bmp":  func(r *bytes.Reader) (image.Image, error) { return bmp.Decode(r) },
+		"tiff": func(r *bytes.Reader) (image.Image, error) { return tiff.Decode(r) },
+	}
+	for name, decode := range lossless {
+		t.Run(name, func(t *testing.T) {
+			f, _ := lookupFormat(name)
+			data, err := encodeImage(sub, f, encodeOptions{})
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			got, err := decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			sameNRGBA(t, sub, got)
+		})
+	}
+
+	t.Run("jpeg", func(t *testing.T) {
+		f, _ := lookupFormat("jpeg")
+		low, err := encodeImage(sub, f, encodeOptions{Quality: 10})
+		if err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+		high, _ := encodeImage(sub, f, encodeOptions{Quality: 100})
+		if len(low) >= len(high) {
+			t.Fatalf("qualidade não afetou o tamanho: q10=%d q100=%d", len(low), len(high))
+		}
+		img, err := jpeg.Decode(bytes.NewReader(high))
+		if err != nil || img.Bounds().Dx() != 30 || img.Bounds().Dy() != 20 {
+			t.Fatalf("jpeg inválido: %v %v", err, img)
+		}
+	})
+
+	t.Run("gif", func(t *testing.T) {
+		f, _ := lookupFormat("gif")
+		data, err := encodeImage(sub, f, encodeOptions{})
+		if err != nil {
+			t.Fatalf("encode: %v", err)
+		}
+		img, err := gif.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		pal := img.(*image.Paletted).Palette
+		if len(pal) == 0 || len(pal) > 256 {
+			t.Fatalf("paleta com %d cores", len(pal))
+		}
+	})
+}
+
+func TestMedianCutQuantizerKeepsFewColorsExact(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 1))
+	want := []color.RGBA{{R: 255, A: 255}, {G: 255, A: 255}, {B: 255, A: 255}, {A: 255}}
+	for x, c := range want {
+		img.SetRGBA(x, 0, c)
+	}
+	pal := medianCutQuantizer{}.Quantize(make(color.Palette, 0, 256), img)
+	if len(pal) != len(want) {
+		t.Fatalf("paleta com %d cores; want %d", len(pal), len(want))
+	}
+	for _, c := range want {
+		if pal.Convert(c) != color.Color(c) {
+			t.Fatalf("cor %v não está na paleta %v", c, pal)
+		}
+	}
+}
+
+func TestDoSaveUsesConfiguredFormat(t *testing.T) {
+	isolatePictures(t, t.TempDir())
+
+	raw := sampleImage(20, 20, false)
+	app := &App{rawBG: raw, cfg: Config{Format: "jpeg", Quality: 80}}
+	app.selX0, app.selY0, app.selX1, app.selY1 = 0, 0, 10, 10
+	app.hasSelection = true
+	app.doSave()
+	if filepath.Ext(app.savedPath) != ".jpg" {
+		t.Fatalf("extensão inesperada: %q (info=%q)", app.savedPath, app.infoMessage)
+	}
+	f, err := os.Open(app.savedPath)
+	if err != nil {
+		t.Fatalf("abrir: %v", err)
+	}
+	defer f.Close()
+	if _, err := jpeg.Decode(f); err != nil {
+		t.Fatalf("arquivo não é JPEG: %v", err)
+	}
+}
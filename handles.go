@@ -0,0 +1,117 @@
+package main
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	handleTolerance = 10 // distância (pixels de tela) que ainda pega a alça
+	gripSize        = 8  // lado do quadradinho desenhado em cada alça
+)
+
+// handleCursor escolhe o cursor de cada alça de selectionHandle.
+func handleCursor(handle int) ebiten.CursorShapeType {
+	switch handle {
+	case 1, 4:
+		return ebiten.CursorShapeNWSEResize
+	case 2, 3:
+		return ebiten.CursorShapeNESWResize
+	case 5, 6:
+		return ebiten.CursorShapeNSResize
+	case 7, 8:
+		return ebiten.CursorShapeEWResize
+	case 9:
+		return ebiten.CursorShapeMove
+	}
+	return ebiten.CursorShapeDefault
+}
+
+// handleGrips devolve o quadradinho (lado size) centrado em cada alça de r,
+// indexado pelo id de selectionHandle (1–8; o índice 0 fica vazio).
+func handleGrips(r image.Rectangle, size int) [9]image.Rectangle {
+	cx, cy := (r.Min.X+r.Max.X)/2, (r.Min.Y+r.Max.Y)/2
+	centers := [9]image.Point{
+		1: r.Min,
+		2: {r.Min.X, r.Max.Y},
+		3: {r.Max.X, r.Min.Y},
+		4: r.Max,
+		5: {cx, r.Min.Y},
+		6: {cx, r.Max.Y},
+		7: {r.Min.X, cy},
+		8: {r.Max.X, cy},
+	}
+	var grips [9]image.Rectangle
+	half := image.Pt(size/2, size/2)
+	for h := 1; h <= 8; h++ {
+		grips[h] = image.Rectangle{Min: centers[h].Sub(half), Max: centers[h].Add(half)}
+	}
+	return grips
+}
+
+// cursorShape decide o cursor para o estado atual com o mouse em (x, y).
+func (a *App) cursorShape(x, y int) ebiten.CursorShapeType {
+	switch {
+	case a.prompting:
+		return ebiten.CursorShapeDefault
+	case a.picking:
+		return ebiten.CursorShapePointer
+	case a.editing || a.tool == toolText:
+		return ebiten.CursorShapeText
+	case a.tool != toolNone, a.selecting:
+		return ebiten.CursorShapeCrosshair
+	case a.hasSelection && !a.adjusting && a.onButton(x, y):
+		return ebiten.CursorShapePointer
+	}
+	handle := 0
+	switch {
+	case a.adjusting:
+		handle = a.adjustHandle
+	case a.hasSelection:
+		handle = a.selectionHandle(x, y)
+	default:
+		return ebiten.CursorShapeCrosshair
+	}
+	if handle != 0 && a.constraint().fixed() {
+		return ebiten.CursorShapeMove // com tamanho fixo as alças só movem
+	}
+	return handleCursor(handle)
+}
+
+// updateCursor troca o cursor do sistema só quando ele muda.
+func (a *App) updateCursor(x, y int) {
+	if shape := a.cursorShape(x, y); shape != a.cursor {
+		a.cursor = shape
+		ebiten.SetCursorShape(shape)
+	}
+}
+
+// drawGrips desenha as alças da seleção travada, destacando a que está sob
+// o mouse (ou sendo arrastada).
+func (a *App) drawGrips(screen *ebiten.Image, r image.Rectangle, mx, my int) {
+	if a.tool != toolNone || r.Empty() {
+		return
+	}
+	scale := max(a.viewScale, 1)
+	active := a.adjustHandle
+	if !a.adjusting {
+		active = a.selectionHandle(mx, my)
+	}
+	size := int(gripSize * scale)
+	border := max(1, int(scale))
+	for h, g := range handleGrips(r, size) {
+		if h == 0 {
+			continue
+		}
+		fill := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+		if h == active {
+			fill = color.NRGBA{R: 70, G: 160, B: 255, A: 255}
+		}
+		o := g.Inset(-border)
+		ebitenutil.DrawRect(screen, float64(o.Min.X), float64(o.Min.Y), float64(o.Dx()), float64(o.Dy()), color.NRGBA{A: 200})
+		ebitenutil.DrawRect(screen, float64(g.Min.X), float64(g.Min.Y), float64(g.Dx()), float64(g.Dy()), fill)
+	}
+}
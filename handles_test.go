@@ -0,0 +1,87 @@
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func lockedApp(r image.Rectangle) *App {
+	app := &App{rawBG: blankCanvas(800, 600)}
+	app.setSelection(r)
+	return app
+}
+
+func TestHandleGripsMatchSelectionHandle(t *testing.T) {
+	r := image.Rect(100, 80, 400, 300)
+	app := lockedApp(r)
+	for h, g := range handleGrips(r, gripSize) {
+		if h == 0 {
+			if !g.Empty() {
+				t.Fatalf("índice 0 deveria ficar vazio: %v", g)
+			}
+			continue
+		}
+		c := g.Min.Add(g.Max).Div(2)
+		if got := app.selectionHandle(c.X, c.Y); got != h {
+			t.Fatalf("alça %d em %v: selectionHandle = %d", h, c, got)
+		}
+		if g.Dx() != gripSize || g.Dy() != gripSize {
+			t.Fatalf("alça %d com tamanho %v", h, g.Size())
+		}
+	}
+}
+
+func TestHandleCursor(t *testing.T) {
+	want := map[int]ebiten.CursorShapeType{
+		0: ebiten.CursorShapeDefault,
+		1: ebiten.CursorShapeNWSEResize, 4: ebiten.CursorShapeNWSEResize,
+		2: ebiten.CursorShapeNESWResize, 3: ebiten.CursorShapeNESWResize,
+		5: ebiten.CursorShapeNSResize, 6: ebiten.CursorShapeNSResize,
+		7: ebiten.CursorShapeEWResize, 8: ebiten.CursorShapeEWResize,
+		9: ebiten.CursorShapeMove,
+	}
+	for h, c := range want {
+		if got := handleCursor(h); got != c {
+			t.Fatalf("handleCursor(%d) = %v, want %v", h, got, c)
+		}
+	}
+}
+
+func TestCursorShapeFollowsState(t *testing.T) {
+	app := &App{rawBG: blankCanvas(800, 600)}
+	if got := app.cursorShape(10, 10); got != ebiten.CursorShapeCrosshair {
+		t.Fatalf("sem seleção: %v", got)
+	}
+
+	app = lockedApp(image.Rect(100, 100, 300, 200))
+	if got := app.cursorShape(300, 200); got != ebiten.CursorShapeNWSEResize {
+		t.Fatalf("hover no canto: %v", got)
+	}
+	if got := app.cursorShape(200, 150); got != ebiten.CursorShapeMove {
+		t.Fatalf("hover dentro: %v", got)
+	}
+	if got := app.cursorShape(700, 500); got != ebiten.CursorShapeDefault {
+		t.Fatalf("fora da seleção: %v", got)
+	}
+
+	// durante o arrasto vale a alça agarrada, não a posição atual
+	app.adjusting, app.adjustHandle = true, 8
+	if got := app.cursorShape(200, 150); got != ebiten.CursorShapeEWResize {
+		t.Fatalf("arrastando a borda: %v", got)
+	}
+	app.adjusting = false
+
+	app.sizePresets = loadSizePresets([]string{"100x50"})
+	app.sizeIdx = 1
+	if got := app.cursorShape(300, 200); got != ebiten.CursorShapeMove {
+		t.Fatalf("tamanho fixo: %v", got)
+	}
+	app.sizeIdx = 0
+
+	app.selectTool(toolRect)
+	if got := app.cursorShape(300, 200); got != ebiten.CursorShapeCrosshair {
+		t.Fatalf("anotando: %v", got)
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import "image"
+
+// command é uma alteração reversível da seleção ou das anotações. Os
+// comandos são registrados depois de aplicados; apply só é chamado no redo.
+type command interface {
+	apply(a *App)
+	revert(a *App)
+}
+
+// history guarda os comandos feitos e desfeitos. Uma nova ação descarta o
+// que havia para refazer.
+type history struct {
+	done   []command
+	undone []command
+}
+
+const maxHistory = 200
+
+func (h *history) push(c command) {
+	h.done = append(h.done, c)
+	if len(h.done) > maxHistory {
+		h.done = h.done[len(h.done)-maxHistory:]
+	}
+	h.undone = nil
+}
+
+func (h *history) undo(a *App) bool {
+	if len(h.done) == 0 {
+		return false
+	}
+	c := h.done[len(h.done)-1]
+	h.done = h.done[:len(h.done)-1]
+	c.revert(a)
+	h.undone = append(h.undone, c)
+	return true
+}
+
+func (h *history) redo(a *App) bool {
+	if len(h.undone) == 0 {
+		return false
+	}
+	c := h.undone[len(h.undone)-1]
+	h.undone = h.undone[:len(h.undone)-1]
+	c.apply(a)
+	h.done = append(h.done, c)
+	return true
+}
+
+// selectionCmd troca o retângulo da seleção (criação, alças, teclado). Um
+// retângulo vazio significa "sem seleção".
+type selectionCmd struct {
+	from, to image.Rectangle
+}
+
+func (c selectionCmd) apply(a *App)  { a.setSelection(c.to) }
+func (c selectionCmd) revert(a *App) { a.setSelection(c.from) }
+
+// clearCmd descarta a seleção junto com as anotações (cancelar, salvar).
+type clearCmd struct {
+	sel         image.Rectangle
+	annotations []annotation
+}
+
+func (c clearCmd) apply(a *App) { a.clearSelection() }
+
+func (c clearCmd) revert(a *App) {
+	a.setSelection(c.sel)
+	a.annotations = append([]annotation(nil), c.annotations...)
+	a.annoDirty = true
+}
+
+// annotationCmd cobre inclusão (before nil), remoção (after nil) e edição
+// da anotação na posição index.
+type annotationCmd struct {
+	index         int
+	before, after *annotation
+}
+
+func (c annotationCmd) apply(a *App)  { a.replaceAnnotation(c.index, c.before, c.after) }
+func (c annotationCmd) revert(a *App) { a.replaceAnnotation(c.index, c.after, c.before) }
+
+// replaceAnnotation troca prev por next na posição i (inserindo ou
+// removendo quando um dos dois é nil).
+func (a *App) replaceAnnotation(i int, prev, next *annotation) {
+	switch {
+	case prev == nil && next != nil:
+		a.annotations = append(a.annotations[:i], append([]annotation{*next}, a.annotations[i:]...)...)
+	case prev != nil && next == nil:
+		a.annotations = append(a.annotations[:i], a.annotations[i+1:]...)
+	case next != nil:
+		a.annotations[i] = *next
+	}
+	a.annoDirty = true
+}
+
+// setSelection aplica um retângulo de seleção sem passar pelo histórico.
+func (a *App) setSelection(r image.Rectangle) {
+	if r.Empty() {
+		a.clearSelection()
+		return
+	}
+	a.selecting, a.adjusting = false, false
+	a.selX0, a.selY0, a.selX1, a.selY1 = r.Min.X, r.Min.Y, r.Max.X, r.Max.Y
+	a.hasSelection = true
+	a.annoDirty = true
+}
+
+// record registra um comando já aplicado.
+func (a *App) record(c command) {
+	a.history.push(c)
+}
+
+// cancelSelection limpa a seleção de forma desfazível.
+func (a *App) cancelSelection() {
+	if a.hasSelection {
+		a.record(clearCmd{sel: a.selectionRect(), annotations: append([]annotation(nil), a.annotations...)})
+	}
+	a.clearSelection()
+}
+
+func (a *App) undo() {
+	if a.selecting || a.adjusting || a.drawing != nil {
+		return
+	}
+	a.finishTextEdit()
+	if a.history.undo(a) {
+		a.infoMessage = "Desfeito."
+	} else {
+		a.infoMessage = "Nada para desfazer."
+	}
+}
+
+func (a *App) redo() {
+	if a.selecting || a.adjusting || a.drawing != nil {
+		return
+	}
+	a.finishTextEdit()
+	if a.history.redo(a) {
+		a.infoMessage = "Refeito."
+	} else {
+		a.infoMessage = "Nada para refazer."
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"image"
+	"testing"
+)
+
+func selectedApp() *App {
+	app := &App{rawBG: blankCanvas(200, 100)}
+	app.setSelection(image.Rect(10, 10, 110, 60))
+	app.record(selectionCmd{to: app.selectionRect()})
+	return app
+}
+
+func TestUndoRedoSelection(t *testing.T) {
+	app := selectedApp()
+
+	// ajuste por alça: 10,10,110,60 -> 10,10,150,80
+	app.setSelection(image.Rect(10, 10, 150, 80))
+	app.record(selectionCmd{from: image.Rect(10, 10, 110, 60), to: app.selectionRect()})
+
+	app.undo()
+	rectEq(t, app.selectionRect(), image.Rect(10, 10, 110, 60))
+	app.undo()
+	if app.hasSelection {
+		t.Fatalf("desfazer a criação deveria remover a seleção")
+	}
+	app.undo() // nada mais a desfazer
+	if app.infoMessage != "Nada para desfazer." {
+		t.Fatalf("mensagem: %q", app.infoMessage)
+	}
+
+	app.redo()
+	app.redo()
+	if !app.hasSelection {
+		t.Fatalf("refazer deveria restaurar a seleção")
+	}
+	rectEq(t, app.selectionRect(), image.Rect(10, 10, 150, 80))
+}
+
+func TestUndoRedoAnnotations(t *testing.T) {
+	app := selectedApp()
+	arrow := annotation{Tool: toolArrow, Points: []image.Point{{20, 20}, {50, 40}}}
+	rect := annotation{Tool: toolRect, Points: []image.Point{{30, 30}, {60, 50}}}
+	app.addAnnotation(arrow)
+	app.addAnnotation(rect)
+	app.removeLastAnnotation()
+
+	app.undo() // desfaz a remoção
+	if len(app.annotations) != 2 || app.annotations[1].Tool != toolRect {
+		t.Fatalf("remoção não desfeita: %+v", app.annotations)
+	}
+	app.undo()
+	app.undo()
+	if len(app.annotations) != 0 {
+		t.Fatalf("inclusões não desfeitas: %+v", app.annotations)
+	}
+	app.redo()
+	if len(app.annotations) != 1 || app.annotations[0].Tool != toolArrow {
+		t.Fatalf("redo: %+v", app.annotations)
+	}
+
+	// uma ação nova descarta o que havia para refazer
+	app.addAnnotation(rect)
+	app.redo()
+	if len(app.annotations) != 2 || app.infoMessage != "Nada para refazer." {
+		t.Fatalf("redo após nova ação: %+v (%q)", app.annotations, app.infoMessage)
+	}
+}
+
+func TestUndoTextEdit(t *testing.T) {
+	app := selectedApp()
+	app.selectTool(toolText)
+	app.placeText(image.Pt(20, 20))
+	app.annotations[app.editIndex].Text = "v1"
+	app.finishTextEdit()
+
+	app.placeText(image.Pt(22, 24)) // reabre a mesma caixa
+	app.annotations[app.editIndex].Text = "v2"
+	app.finishTextEdit()
+
+	app.undo()
+	if len(app.annotations) != 1 || app.annotations[0].Text != "v1" {
+		t.Fatalf("edição não desfeita: %+v", app.annotations)
+	}
+	app.undo()
+	if len(app.annotations) != 0 {
+		t.Fatalf("inclusão do texto não desfeita")
+	}
+	app.redo()
+	app.redo()
+	if len(app.annotations) != 1 || app.annotations[0].Text != "v2" {
+		t.Fatalf("redo do texto: %+v", app.annotations)
+	}
+}
+
+func TestUndoCancelRestoresAnnotations(t *testing.T) {
+	app := selectedApp()
+	app.addAnnotation(annotation{Tool: toolLine, Points: []image.Point{{20, 20}, {80, 20}}})
+	app.cancelSelection()
+	if app.hasSelection || len(app.annotations) != 0 {
+		t.Fatalf("cancelar deveria limpar tudo")
+	}
+
+	app.undo()
+	if !app.hasSelection || len(app.annotations) != 1 {
+		t.Fatalf("desfazer o cancelamento: sel=%v annos=%d", app.hasSelection, len(app.annotations))
+	}
+	rectEq(t, app.selectionRect(), image.Rect(10, 10, 110, 60))
+
+	app.redo()
+	if app.hasSelection || len(app.annotations) != 0 {
+		t.Fatalf("refazer o cancelamento")
+	}
+}
+
+func TestHistoryIsBounded(t *testing.T) {
+	var h history
+	for i := 0; i < maxHistory+50; i++ {
+		h.push(selectionCmd{})
+	}
+	if len(h.done) != maxHistory {
+		t.Fatalf("histórico com %d comandos", len(h.done))
+	}
+}
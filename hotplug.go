@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"slices"
+	"time"
+)
+
+// displayPollInterval é o intervalo entre as consultas à lista de displays
+// quando a plataforma não avisa das mudanças; a janela recuperar o foco
+// também dispara uma consulta na hora.
+const displayPollInterval = 10 * time.Second
+
+// displayWatcher re-enumera os displays fora do loop do jogo (no X11 cada
+// consulta abre uma conexão nova com o servidor por display). Com changes
+// (eventos RandR no X11) só consulta quando avisado ou ao recuperar o foco;
+// sem ele, consulta também a cada displayPollInterval.
+type displayWatcher struct {
+	changes <-chan struct{} // avisos de mudança da plataforma (nil = não há)
+	next    time.Time       // próxima consulta periódica
+	pending bool            // consulta em curso
+	focused bool
+	results chan []image.Rectangle
+	notice  string // aviso a mostrar quando a recaptura chegar
+}
+
+// reconcileDisplay acha o display cur de old na lista nova: pelos mesmos
+// limites (o índice pode ter mudado) ou, se ele saiu ou mudou de resolução,
+// o que mais se sobrepõe a ele (senão o primeiro). kept informa se o monitor
+// continua igual.
+func reconcileDisplay(old []image.Rectangle, cur int, fresh []image.Rectangle) (idx int, kept bool) {
+	if cur < 0 || cur >= len(old) {
+		return 0, false
+	}
+	b := old[cur]
+	for i, d := range fresh {
+		if d == b {
+			return i, true
+		}
+	}
+	best, area := 0, 0
+	for i, d := range fresh {
+		in := d.Intersect(b)
+		if n := in.Dx() * in.Dy(); n > area {
+			best, area = i, n
+		}
+	}
+	return best, false
+}
+
+// watchDisplays roda a cada Update: entrega o resultado da consulta em curso
+// e inicia outra quando a plataforma avisa de uma mudança, a janela volta ao
+// foco ou (sem avisos) o intervalo vence.
+func (a *App) watchDisplays(now time.Time, focused bool) {
+	w := &a.hotplug
+	if w.results == nil {
+		w.results = make(chan []image.Rectangle, 1)
+	}
+	if w.pending {
+		select {
+		case displays := <-w.results:
+			w.pending = false
+			a.applyDisplays(displays, now)
+		default:
+			return
+		}
+	}
+	regained := focused && !w.focused
+	w.focused = focused
+	changed := false
+	if w.changes != nil {
+		select {
+		case <-w.changes:
+			changed = true
+		default:
+		}
+	}
+	due := w.changes == nil && !now.Before(w.next)
+	if !regained && !changed && !due {
+		return
+	}
+	w.next = now.Add(displayPollInterval)
+	w.pending = true
+	c, results := a.capturer, w.results
+	go func() { results <- c.Displays() }()
+}
+
+// applyDisplays troca a lista de displays pela nova e acerta curDisp. Se a
+// imagem na tela deixou de corresponder a um monitor (o capturado saiu ou
+// mudou de resolução; no modo todos, o desktop virtual mudou), recaptura.
+// Uma lista vazia é ignorada: costuma ser passageira durante a troca.
+func (a *App) applyDisplays(fresh []image.Rectangle, now time.Time) {
+	if len(fresh) == 0 || slices.Equal(fresh, a.displays) {
+		return
+	}
+	old := a.displays
+	idx, kept := reconcileDisplay(old, a.curDisp, fresh)
+	a.displays = fresh
+	a.curDisp = idx
+
+	stale := !kept
+	msg := fmt.Sprintf("Monitores alterados: %d ativo(s).", len(fresh))
+	if a.modeAll {
+		stale = virtualBounds(fresh) != virtualBounds(old)
+		msg = fmt.Sprintf("Monitores alterados: desktop recapturado (%d ativo(s)).", len(fresh))
+	} else if !kept {
+		msg = fmt.Sprintf("O monitor capturado mudou ou foi desconectado; mostrando o monitor %d/%d.", idx+1, len(fresh))
+	}
+	if !stale && a.captureErr == nil {
+		a.infoMessage = msg
+		return
+	}
+	a.captureErr = nil
+	a.picking = false
+	a.clearSelection()
+	a.history = history{}
+	a.hotplug.notice = msg
+	a.recapture(now)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"image"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitDisplays roda watchDisplays até a consulta em curso ser entregue.
+func waitDisplays(t *testing.T, app *App, now time.Time) {
+	t.Helper()
+	app.watchDisplays(now, false)
+	deadline := time.Now().Add(5 * time.Second)
+	for app.hotplug.pending {
+		if time.Now().After(deadline) {
+			t.Fatal("lista de displays não chegou")
+		}
+		time.Sleep(time.Millisecond)
+		app.watchDisplays(now, false)
+	}
+}
+
+func TestReconcileDisplay(t *testing.T) {
+	left, right := image.Rect(-40, 0, 0, 30), image.Rect(0, 0, 60, 40)
+	bigger := image.Rect(0, 0, 80, 60) // o da direita com outra resolução
+	tests := []struct {
+		name     string
+		old      []image.Rectangle
+		cur      int
+		fresh    []image.Rectangle
+		wantIdx  int
+		wantKept bool
+	}{
+		{"unchanged", []image.Rectangle{left, right}, 1, []image.Rectangle{left, right}, 1, true},
+		{"index_shifted", []image.Rectangle{left, right}, 1, []image.Rectangle{right}, 0, true},
+		{"unplugged", []image.Rectangle{left, right}, 0, []image.Rectangle{right}, 0, false},
+		{"resized", []image.Rectangle{left, right}, 1, []image.Rectangle{left, bigger}, 1, false},
+		{"out_of_range", []image.Rectangle{left}, 3, []image.Rectangle{left, right}, 0, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			idx, kept := reconcileDisplay(tc.old, tc.cur, tc.fresh)
+			if idx != tc.wantIdx || kept != tc.wantKept {
+				t.Fatalf("reconcileDisplay = %d, %v; want %d, %v", idx, kept, tc.wantIdx, tc.wantKept)
+			}
+		})
+	}
+}
+
+func TestHotplugKeepsCurrentDisplay(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	c := newFakeCapturer(desktop, displays...)
+	app := &App{capturer: c, displays: c.Displays(), curDisp: 1, rawBG: blankCanvas(60, 40)}
+	app.setSelection(image.Rect(5, 5, 20, 20))
+
+	now := time.Now()
+	c.SetDisplays(displays[1]) // sai o monitor da esquerda
+	waitDisplays(t, app, now)
+	if len(app.displays) != 1 || app.curDisp != 0 {
+		t.Fatalf("displays=%v curDisp=%d; want só o da direita, índice 0", app.displays, app.curDisp)
+	}
+	if app.counting() || !app.hasSelection {
+		t.Fatalf("o monitor capturado continua: não deveria recapturar nem perder a seleção")
+	}
+	if !strings.Contains(app.infoMessage, "Monitores alterados") {
+		t.Fatalf("o usuário deveria ser avisado: %q", app.infoMessage)
+	}
+
+	// antes do intervalo, sem foco novo, não consulta de novo
+	app.watchDisplays(now.Add(time.Second), false)
+	if app.hotplug.pending {
+		t.Fatalf("consulta antes do intervalo")
+	}
+	// recuperar o foco consulta na hora
+	app.watchDisplays(now.Add(time.Second), true)
+	if !app.hotplug.pending {
+		t.Fatalf("recuperar o foco deveria consultar os displays")
+	}
+}
+
+func TestHotplugRecapturesWhenDisplayGone(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	c := newFakeCapturer(desktop, displays...)
+	app := &App{capturer: c, displays: c.Displays(), curDisp: 0, rawBG: blankCanvas(40, 30)}
+	app.setSelection(image.Rect(5, 5, 20, 20))
+
+	c.SetDisplays(displays[1]) // sai justamente o monitor capturado
+	waitDisplays(t, app, time.Now())
+	if !app.counting() || app.hasSelection {
+		t.Fatalf("deveria limpar a seleção e recapturar")
+	}
+	finishCapture(t, app)
+	rectEq(t, app.rawBG.Bounds(), image.Rect(0, 0, 60, 40))
+	if !strings.Contains(app.infoMessage, "desconectado") {
+		t.Fatalf("aviso deveria aparecer depois da recaptura: %q", app.infoMessage)
+	}
+}
+
+func TestHotplugWaitsForChangeEvents(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	c := newFakeCapturer(desktop, displays...)
+	changes := make(chan struct{}, 1)
+	app := &App{capturer: c, displays: c.Displays(), curDisp: 1, rawBG: blankCanvas(60, 40)}
+	app.hotplug.changes = changes
+
+	// com avisos da plataforma não há consulta periódica
+	now := time.Now()
+	app.watchDisplays(now, false)
+	app.watchDisplays(now.Add(time.Hour), false)
+	if app.hotplug.pending {
+		t.Fatalf("sem aviso nem foco novo não deveria consultar")
+	}
+
+	c.SetDisplays(displays[1])
+	changes <- struct{}{}
+	waitDisplays(t, app, now.Add(time.Hour))
+	if len(app.displays) != 1 || app.curDisp != 0 {
+		t.Fatalf("o aviso deveria atualizar os displays: %v, %d", app.displays, app.curDisp)
+	}
+}
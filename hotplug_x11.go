@@ -0,0 +1,56 @@
+//go:build linux || freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/randr"
+	"github.com/jezek/xgb/xproto"
+)
+
+// platformDisplayChanges assina os eventos RandR de mudança de tela em uma
+// única conexão X, aberta enquanto o app roda: cada monitor conectado,
+// desconectado ou reconfigurado gera um aviso no canal. Sem servidor X ou
+// sem RandR devolve nil (fica só a consulta periódica).
+func platformDisplayChanges() <-chan struct{} {
+	conn, err := xgb.NewConn()
+	if err != nil {
+		return nil
+	}
+	if err := randr.Init(conn); err != nil {
+		conn.Close()
+		return nil
+	}
+	// os eventos de CRTC/saída só chegam a quem declara RandR 1.2
+	if _, err := randr.QueryVersion(conn, 1, 2).Reply(); err != nil {
+		conn.Close()
+		return nil
+	}
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	mask := uint16(randr.NotifyMaskScreenChange | randr.NotifyMaskCrtcChange | randr.NotifyMaskOutputChange)
+	if err := randr.SelectInputChecked(conn, root, mask).Check(); err != nil {
+		conn.Close()
+		return nil
+	}
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer conn.Close()
+		for {
+			ev, xerr := conn.WaitForEvent()
+			if ev == nil && xerr == nil {
+				return // conexão fechada
+			}
+			switch ev.(type) {
+			case randr.ScreenChangeNotifyEvent, randr.NotifyEvent:
+				// uma troca de monitor gera uma rajada de eventos; basta
+				// um aviso pendente
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return changes
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"unicode/utf8"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+const (
+	loupeCells       = 15 // pixels amostrados por lado (ímpar: o central é o alvo)
+	loupeMinZoom     = 8
+	loupeMaxZoom     = 16
+	loupeDefaultZoom = 10
+	loupeMargin      = 24 // distância do cursor, em pixels de tela
+)
+
+// loupeSample copia os n×n pixels de img centrados em c. O que cai fora da
+// imagem fica transparente.
+func loupeSample(img *image.RGBA, c image.Point, n int) *image.RGBA {
+	out := image.NewRGBA(image.Rect(0, 0, n, n))
+	half := n / 2
+	b := img.Bounds()
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			p := image.Pt(c.X-half+x, c.Y-half+y)
+			if p.In(b) {
+				out.SetRGBA(x, y, img.RGBAAt(p.X, p.Y))
+			}
+		}
+	}
+	return out
+}
+
+// pixelLabel descreve o pixel sob a mira: coordenadas, RGB e hex.
+func pixelLabel(img *image.RGBA, p image.Point) string {
+	if !p.In(img.Bounds()) {
+		return fmt.Sprintf("%d,%d", p.X, p.Y)
+	}
+	c := img.RGBAAt(p.X, p.Y)
+	return fmt.Sprintf("%d,%d  RGB(%d,%d,%d)  #%02X%02X%02X", p.X, p.Y, c.R, c.G, c.B, c.R, c.G, c.B)
+}
+
+// loupePlacement posiciona a lupa (size×size) abaixo e à direita do cursor,
+// trocando de lado quando não cabe na tela.
+func loupePlacement(cursor image.Point, size, margin int, screen image.Rectangle) image.Point {
+	x, y := cursor.X+margin, cursor.Y+margin
+	if x+size > screen.Max.X {
+		x = cursor.X - margin - size
+	}
+	if y+size > screen.Max.Y {
+		y = cursor.Y - margin - size
+	}
+	x = max(screen.Min.X, x)
+	y = max(screen.Min.Y, y)
+	return image.Pt(x, y)
+}
+
+func clampZoom(z int) int {
+	return max(loupeMinZoom, min(z, loupeMaxZoom))
+}
+
+// updateLoupe ajusta o zoom pela roda do mouse durante o arrasto.
+func (a *App) updateLoupe() {
+	if a.loupeZoom == 0 {
+		a.loupeZoom = loupeDefaultZoom
+	}
+	if !a.selecting && !a.adjusting {
+		return
+	}
+	if _, dy := ebiten.Wheel(); dy > 0 {
+		a.loupeZoom = clampZoom(a.loupeZoom + 1)
+	} else if dy < 0 {
+		a.loupeZoom = clampZoom(a.loupeZoom - 1)
+	}
+}
+
+// drawLoupe desenha a lupa durante a seleção e o ajuste. A tela está em
+// pixels de rawBG e a janela pode estar reduzida, então os tamanhos são
+// multiplicados por viewScale para o zoom valer em pixels reais de tela.
+func (a *App) drawLoupe(screen *ebiten.Image) {
+	if a.loupeOff || (!a.selecting && !a.adjusting) || a.rawBG == nil {
+		return
+	}
+	scale := a.viewScale
+	if scale <= 0 {
+		scale = 1
+	}
+	zoom := clampZoom(a.loupeZoom)
+	cell := float64(zoom) * scale
+	size := int(cell * loupeCells)
+
+	mx, my := ebiten.CursorPosition()
+	cursor := image.Pt(mx, my)
+	pos := loupePlacement(cursor, size, int(loupeMargin*scale), screen.Bounds())
+	px, py := float64(pos.X), float64(pos.Y)
+
+	if a.loupeImg == nil {
+		a.loupeImg = ebiten.NewImage(loupeCells, loupeCells)
+	}
+	a.loupeImg.WritePixels(loupeSample(a.rawBG, cursor, loupeCells).Pix)
+
+	ebitenutil.DrawRect(screen, px, py, float64(size), float64(size), color.NRGBA{R: 20, G: 20, B: 20, A: 255})
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(cell, cell)
+	op.GeoM.Translate(px, py)
+	screen.DrawImage(a.loupeImg, op)
+
+	// grade de pixels
+	grid := color.NRGBA{R: 0, G: 0, B: 0, A: 70}
+	for i := 1; i < loupeCells; i++ {
+		d := float64(i) * cell
+		ebitenutil.DrawRect(screen, px+d, py, scale, float64(size), grid)
+		ebitenutil.DrawRect(screen, px, py+d, float64(size), scale, grid)
+	}
+	// mira no pixel central
+	c := float64(loupeCells/2) * cell
+	th := 2 * scale
+	red := color.NRGBA{R: 255, G: 40, B: 40, A: 255}
+	ebitenutil.DrawRect(screen, px+c, py+c, cell, th, red)
+	ebitenutil.DrawRect(screen, px+c, py+c+cell-th, cell, th, red)
+	ebitenutil.DrawRect(screen, px+c, py+c, th, cell, red)
+	ebitenutil.DrawRect(screen, px+c+cell-th, py+c, th, cell, red)
+	drawRectBorder(screen, pos.X, pos.Y, pos.X+size, pos.Y+size)
+
+	a.drawScaledLabel(screen, pixelLabel(a.rawBG, cursor), px, py+float64(size)+2*scale, scale)
+}
+
+// drawScaledLabel escreve um texto de depuração compensando a redução da
+// janela, para que continue legível.
+func (a *App) drawScaledLabel(screen *ebiten.Image, text string, x, y, scale float64) {
+	w := utf8.RuneCountInString(text)*6 + 8
+	if a.labelImg == nil || a.labelImg.Bounds().Dx() < w {
+		a.labelImg = ebiten.NewImage(max(w, 256), 18)
+	}
+	a.labelImg.Fill(color.NRGBA{R: 20, G: 20, B: 20, A: 220})
+	ebitenutil.DebugPrintAt(a.labelImg, text, 4, 1)
+	sub := a.labelImg.SubImage(image.Rect(0, 0, w, 18)).(*ebiten.Image)
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(x, y)
+	screen.DrawImage(sub, op)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestLoupeSample(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	target := color.RGBA{R: 1, G: 2, B: 3, A: 255}
+	img.SetRGBA(10, 10, target)
+	img.SetRGBA(0, 0, color.RGBA{R: 9, A: 255})
+
+	s := loupeSample(img, image.Pt(10, 10), loupeCells)
+	if s.Bounds() != image.Rect(0, 0, loupeCells, loupeCells) {
+		t.Fatalf("bounds: %v", s.Bounds())
+	}
+	if s.RGBAAt(loupeCells/2, loupeCells/2) != target {
+		t.Fatalf("pixel central: %v", s.RGBAAt(loupeCells/2, loupeCells/2))
+	}
+
+	// no canto, o que sai da imagem fica transparente
+	s = loupeSample(img, image.Pt(0, 0), 5)
+	if s.RGBAAt(2, 2) != (color.RGBA{R: 9, A: 255}) {
+		t.Fatalf("centro no canto: %v", s.RGBAAt(2, 2))
+	}
+	if s.RGBAAt(0, 0).A != 0 || s.RGBAAt(1, 2).A != 0 {
+		t.Fatalf("fora da imagem deveria ser transparente")
+	}
+}
+
+func TestPixelLabel(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	img.SetRGBA(3, 4, color.RGBA{R: 255, G: 16, B: 0, A: 255})
+	if got, want := pixelLabel(img, image.Pt(3, 4)), "3,4  RGB(255,16,0)  #FF1000"; got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+	if got := pixelLabel(img, image.Pt(-1, 4)); got != "-1,4" {
+		t.Fatalf("fora da imagem: %q", got)
+	}
+}
+
+func TestLoupePlacement(t *testing.T) {
+	screen := image.Rect(0, 0, 800, 600)
+	cases := []struct {
+		cursor image.Point
+		want   image.Point
+	}{
+		{image.Pt(100, 100), image.Pt(124, 124)}, // abaixo/direita
+		{image.Pt(750, 100), image.Pt(576, 124)}, // sem espaço à direita
+		{image.Pt(100, 580), image.Pt(124, 406)}, // sem espaço abaixo
+		{image.Pt(10, 10), image.Pt(34, 34)},
+	}
+	for _, c := range cases {
+		if got := loupePlacement(c.cursor, 150, 24, screen); got != c.want {
+			t.Fatalf("cursor %v: got %v want %v", c.cursor, got, c.want)
+		}
+	}
+}
+
+func TestClampZoom(t *testing.T) {
+	for in, want := range map[int]int{0: loupeMinZoom, 12: 12, 40: loupeMaxZoom} {
+		if got := clampZoom(in); got != want {
+			t.Fatalf("clampZoom(%d) = %d, want %d", in, got, want)
+		}
+	}
+}
@@ -1,21 +1,22 @@
 package main
 
 import (
-	"bytes"
+	"errors"
+	"flag"
 	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
-	"image/png"
 	"os"
 	"path/filepath"
-	"runtime"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
-	"github.com/kbinani/screenshot"
 )
 
 type Button struct {
@@ -46,8 +47,17 @@ type App struct {
 	bg             *ebiten.Image
 	rawBG          *image.RGBA
 	overlay        *ebiten.Image
-	captureCh      chan *image.RGBA
+	captures       *capturePipeline
 	captureStarted bool
+	captured       bool  // rawBG já é uma captura de verdade
+	captureErr     error // última captura falhou: tela de erro no lugar da imagem
+
+	// captura com atraso (--delay, tecla D)
+	startDelay    int
+	delayEnd      time.Time // zero = sem contagem
+	delayShown    int       // último segundo mostrado no título
+	restoreWindow bool      // a janela foi minimizada para a captura
+	captureSince  time.Time // início da captura em curso
 
 	// seleção
 	selecting      bool // mouse arrastando
@@ -64,17 +74,70 @@ type App struct {
 	origSelY0      int
 	origSelX1      int
 	origSelY1      int
+	nudge          nudgeState // ajuste pelo teclado em curso (setas seguradas)
 
 	// UI/estado
 	saveBtn     Button
 	cancelBtn   Button
+	copyBtn     Button
 	savedPath   string
 	infoMessage string
+	cfg         Config // formato/qualidade de saída
+
+	// anotações (coordenadas de rawBG)
+	tool          annotationTool
+	annotations   []annotation
+	drawing       *annotation // forma em andamento
+	annoColor     int         // índice em annotationColors
+	annoWidth     int
+	annoLayer     *ebiten.Image
+	annoLayerPos  image.Point
+	annoLayerRect image.Rectangle
+	annoDirty     bool
+	textSize      int
+	textPill      bool
+	editing       bool // caixa de texto recebendo digitação
+	editIndex     int
+	editOrig      *annotation // texto antes da edição (nil se a caixa é nova)
+
+	history history // desfazer/refazer
+
+	// entrada exata x,y,w,h
+	prompting  bool
+	promptText string
+
+	// proporção / tamanho fixo da seleção
+	ratioIdx    int // em ratioPresets
+	sizeIdx     int // 0 = desligado, senão sizePresets[sizeIdx-1]
+	sizePresets []selectionConstraint
+
+	// lupa
+	loupeZoom int
+	loupeOff  bool
+	loupeImg  *ebiten.Image
+	labelImg  *ebiten.Image
+	viewScale float64 // pixels de rawBG por pixel de janela
+	cursor    ebiten.CursorShapeType
+
+	// captura de janela (W)
+	windowLister WindowLister
+	windows      []windowInfo // janelas no momento da captura
+	windowsErr   error
+	picking      bool
+
+	// ímã (Ctrl): mapa de bordas de rawBG, refeito em segundo plano quando a
+	// captura muda
+	edgeCache *edgeMap
+	edgeSrc   *image.RGBA
+	edgeReady chan *edgeMap
 
 	// multi-monitor
-	displays []image.Rectangle
-	curDisp  int
-	modeAll  bool
+	capturer  Capturer
+	clipboard Clipboard
+	displays  []image.Rectangle
+	curDisp   int
+	modeAll   bool
+	hotplug   displayWatcher // monitores conectados/desconectados
 }
 
 // Valores de versão embutidos via -ldflags (ver Makefile)
@@ -85,29 +148,52 @@ var (
 )
 
 func main() {
-	n := screenshot.NumActiveDisplays()
-	if n <= 0 {
+	cfg, err := loadConfig(configPath())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Aviso: configuração ignorada:", err)
+	}
+	capturer, err := newCapturer()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Erro ao iniciar captura:", err)
+		os.Exit(1)
+	}
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		os.Exit(runCLI(capturer, cfg, os.Args[1:], os.Stdout, os.Stderr))
+	}
+	startDelay, err := parseGUIFlags(&cfg, os.Args[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		os.Exit(exitUsage)
+	}
+
+	displays := capturer.Displays()
+	if len(displays) == 0 {
 		fmt.Println("Nenhum display ativo.")
 		return
 	}
-	displays := make([]image.Rectangle, 0, n)
-	for i := 0; i < n; i++ {
-		displays = append(displays, screenshot.GetDisplayBounds(i))
-	}
 
 	bounds := displays[0]
 	raw := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
 	bg := ebiten.NewImageFromImage(raw)
 
 	app := &App{
-		bg:          bg,
-		rawBG:       raw,
-		infoMessage: "Arraste para selecionar. Solte para ver opções. Enter=Salvar | Esc=Cancelar | Q/E trocar monitor | A 'todos'",
-		displays:    displays,
-		curDisp:     0,
-		modeAll:     false,
-		captureCh:   make(chan *image.RGBA, 1),
-	}
+		bg:           bg,
+		rawBG:        raw,
+		infoMessage:  readyMessage,
+		cfg:          cfg,
+		capturer:     capturer,
+		clipboard:    newClipboard(),
+		windowLister: newWindowLister(),
+		sizePresets:  loadSizePresets(cfg.SizePresets),
+		displays:     displays,
+		curDisp:      0,
+		modeAll:      false,
+		captures:     newCapturePipeline(),
+		startDelay:   startDelay,
+	}
+	app.hotplug.changes = platformDisplayChanges()
 	app.layoutButtons(raw.Bounds().Dx(), raw.Bounds().Dy())
 
 	w, h := raw.Bounds().Dx(), raw.Bounds().Dy()
@@ -130,58 +216,203 @@ func main() {
 	if err := ebiten.RunGame(app); err != nil {
 		panic(err)
 	}
+	// a imagem copiada não pode sumir junto com o processo
+	if k, ok := app.clipboard.(clipboardKeeper); ok {
+		if err := k.keep(2 * time.Second); err != nil {
+			fmt.Fprintln(os.Stderr, "Aviso:", err)
+		}
+	}
 }
 
 func (a *App) Update() error {
 	if !a.captureStarted {
 		a.captureStarted = true
-		a.infoMessage = "Capturando tela..."
-		go func() {
-			a.captureCh <- captureDisplay(0)
-		}()
-	}
-	select {
-	case raw := <-a.captureCh:
-		if raw != nil {
-			a.rawBG = raw
-			a.bg = ebiten.NewImageFromImage(raw)
-			a.layoutButtons(raw.Bounds().Dx(), raw.Bounds().Dy())
-			a.infoMessage = "Arraste para selecionar. Solte para ver opções. Enter=Salvar | Esc=Cancelar | Q/E trocar monitor | A 'todos'"
+		a.startDelayedCapture(a.startDelay, time.Now())
+	}
+	a.pollCapture()
+	a.revealSlowCapture(time.Now())
+	a.watchDisplays(time.Now(), ebiten.IsFocused())
+
+	// Contagem da captura com atraso: a janela está minimizada
+	if a.counting() {
+		if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+			a.cancelDelayedCapture()
+			return nil
 		}
-	default:
+		a.updateDelayedCapture(time.Now())
+		return nil
+	}
+
+	// Captura falhou: só as opções da tela de erro
+	if a.captureErr != nil {
+		return a.updateCaptureError()
 	}
 
-	// Sair/cancelar com Esc
+	// Antes da primeira captura o fundo é um placeholder: nada a selecionar
+	if !a.captured {
+		if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+			return ebiten.Termination
+		}
+		return nil
+	}
+
+	// Recaptura em curso: Esc desiste dela e mantém a imagem atual
+	if a.capturing() && inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		a.cancelCapture()
+		return nil
+	}
+
+	// Caixa de texto em edição: o teclado vai todo para o texto
+	if a.editing {
+		a.updateTextInput()
+		mx, my := ebiten.CursorPosition()
+		a.updateAnnotating(mx, my)
+		a.updateButtons(mx, my)
+		a.updateCursor(mx, my)
+		return nil
+	}
+
+	// Prompt x,y,w,h aberto: idem
+	if a.prompting {
+		a.updateRegionPrompt()
+		a.updateCursor(ebiten.CursorPosition())
+		return nil
+	}
+
+	// Sair/cancelar com Esc (primeiro sai da ferramenta de anotação)
 	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
-		if a.hasSelection || a.selecting {
-			a.clearSelection()
+		if a.picking {
+			a.picking = false
+			a.infoMessage = "Escolha de janela cancelada."
+		} else if a.tool != toolNone {
+			a.selectTool(toolNone)
+		} else if a.hasSelection || a.selecting {
+			a.cancelSelection()
 			a.infoMessage = "Seleção cancelada."
 		} else {
 			return ebiten.Termination
 		}
 	}
 
+	// Ctrl+Z desfaz, Ctrl+Shift+Z (ou Ctrl+Y) refaz
+	if isCtrlPressed() && inpututil.IsKeyJustPressed(ebiten.KeyZ) {
+		if ebiten.IsKeyPressed(ebiten.KeyShift) {
+			a.redo()
+		} else {
+			a.undo()
+		}
+	}
+	if isCtrlPressed() && inpututil.IsKeyJustPressed(ebiten.KeyY) {
+		a.redo()
+	}
+
 	// Enter = salvar (se houver seleção pronta)
 	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) && a.hasSelection {
 		a.doSave()
 	}
 
+	// Ctrl+C = copiar a seleção para a área de transferência
+	if a.hasSelection && isCtrlPressed() && inpututil.IsKeyJustPressed(ebiten.KeyC) {
+		a.doCopy()
+	}
+
+	// Anotações: 1-5 formas, 6-8 tarjas, 0 volta a ajustar a seleção
+	if a.hasSelection {
+		for key, tool := range map[ebiten.Key]annotationTool{
+			ebiten.Key0: toolNone,
+			ebiten.Key1: toolRect,
+			ebiten.Key2: toolEllipse,
+			ebiten.Key3: toolArrow,
+			ebiten.Key4: toolLine,
+			ebiten.Key5: toolPen,
+			ebiten.Key6: toolPixelate,
+			ebiten.Key7: toolBlur,
+			ebiten.Key8: toolBlackout,
+			ebiten.KeyT: toolText,
+		} {
+			if inpututil.IsKeyJustPressed(key) {
+				a.selectTool(tool)
+			}
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) || inpututil.IsKeyJustPressed(ebiten.KeyDelete) {
+			a.removeLastAnnotation()
+		}
+	}
+	if a.tool != toolNone {
+		if !isCtrlPressed() && inpututil.IsKeyJustPressed(ebiten.KeyC) {
+			a.annoColor = (a.annoColor + 1) % len(annotationColors)
+		}
+		if a.tool == toolText {
+			if inpututil.IsKeyJustPressed(ebiten.KeyMinus) {
+				a.textSize = max(minTextSize, a.textSize-2)
+			}
+			if inpututil.IsKeyJustPressed(ebiten.KeyEqual) {
+				a.textSize = min(maxTextSize, a.textSize+2)
+			}
+			if inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+				a.textPill = !a.textPill
+			}
+		} else {
+			if inpututil.IsKeyJustPressed(ebiten.KeyMinus) {
+				a.annoWidth = max(1, a.annoWidth-1)
+			}
+			if inpututil.IsKeyJustPressed(ebiten.KeyEqual) {
+				a.annoWidth = min(maxStrokeWidth, a.annoWidth+1)
+			}
+		}
+	}
+
+	// Teclado: setas movem/redimensionam, I abre a entrada exata x,y,w,h,
+	// B recorta as bordas uniformes
+	a.updateSelectionKeys()
+	if inpututil.IsKeyJustPressed(ebiten.KeyI) && !a.selecting && !a.adjusting {
+		a.openRegionPrompt()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyB) && !a.selecting && !a.adjusting && a.drawing == nil {
+		a.trimSelection()
+	}
+	// D recaptura com atraso (menus, tooltips)
+	if inpututil.IsKeyJustPressed(ebiten.KeyD) && !a.picking && !a.selecting && !a.adjusting && a.drawing == nil {
+		a.startDelayedCapture(max(a.cfg.Delay, 1), time.Now())
+	}
+	// W escolhe uma janela aberta
+	if inpututil.IsKeyJustPressed(ebiten.KeyW) && !a.picking && !a.selecting && !a.adjusting && a.drawing == nil {
+		a.selectTool(toolNone)
+		a.startWindowPick()
+	}
+
+	// Proporção (R) e tamanho fixo (P)
+	if inpututil.IsKeyJustPressed(ebiten.KeyR) && !a.selecting && !a.adjusting {
+		a.cycleRatio()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyP) && !a.selecting && !a.adjusting {
+		a.cycleSize()
+	}
+
+	// Lupa: M liga/desliga, roda do mouse ajusta o zoom durante o arrasto
+	if inpututil.IsKeyJustPressed(ebiten.KeyM) {
+		a.loupeOff = !a.loupeOff
+	}
+	a.updateLoupe()
+
+	// Trocar formato de saída (F)
+	if inpututil.IsKeyJustPressed(ebiten.KeyF) {
+		a.cfg.Format = nextFormat(a.cfg.Format).Name
+		f, _ := lookupFormat(a.cfg.Format)
+		a.infoMessage = "Formato de saída: " + f.Label()
+	}
+
 	// Alternar modo (A)
 	if inpututil.IsKeyJustPressed(ebiten.KeyA) {
 		a.modeAll = !a.modeAll
 		a.clearSelection()
-		if a.modeAll {
-			raw := captureAllDisplays()
-			a.rawBG = raw
-			a.bg = ebiten.NewImageFromImage(raw)
-			ebiten.SetWindowTitle("Snip - Seleção de área (todos monitores)")
-		} else {
-			raw := captureDisplay(a.curDisp)
-			a.rawBG = raw
-			a.bg = ebiten.NewImageFromImage(raw)
-			ebiten.SetWindowTitle("Snip - Seleção de área (1 monitor)")
-		}
-		a.layoutButtons(a.rawBG.Bounds().Dx(), a.rawBG.Bounds().Dy())
+		a.history = history{} // coordenadas passam a ser de outra imagem
+		a.recapture(time.Now())
+	}
+
+	// F5 atualiza a captura (a seleção fica se o tamanho não mudar)
+	if inpututil.IsKeyJustPressed(ebiten.KeyF5) && !a.selecting && !a.adjusting && a.drawing == nil {
+		a.recapture(time.Now())
 	}
 
 	// Trocar monitor (apenas modo 1 monitor)
@@ -195,23 +426,38 @@ func (a *App) Update() error {
 	// Mouse
 	mx, my := ebiten.CursorPosition()
 
+	// Escolha de janela: o clique seleciona o quadro sob o mouse
+	if a.picking {
+		a.updateWindowPick(mx, my)
+		a.updateCursor(mx, my)
+		return nil
+	}
+
 	// Início e atualização do arrasto
-	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
-		if a.hasSelection && !a.adjusting && !a.saveBtn.Contains(mx, my) && !a.cancelBtn.Contains(mx, my) {
+	if a.tool != toolNone {
+		a.updateAnnotating(mx, my)
+	} else if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		if a.hasSelection && !a.adjusting && !a.onButton(mx, my) {
 			a.adjusting = true
 			a.adjustHandle = a.selectionHandle(mx, my)
 			a.adjustStartX, a.adjustStartY = mx, my
 			a.origSelX0, a.origSelY0 = a.selX0, a.selY0
 			a.origSelX1, a.origSelY1 = a.selX1, a.selY1
 		} else if a.selecting {
-			a.curX, a.curY = mx, my
+			a.curX, a.curY = a.constrainedCursor(mx, my)
+			if a.snapping() {
+				a.snapSelecting()
+			}
 		} else if !a.hasSelection {
 			a.selecting = true
 			a.startX, a.startY = mx, my
-			a.curX, a.curY = mx, my
+			a.curX, a.curY = a.constrainedCursor(mx, my)
 			a.savedPath = ""
 		} else if a.adjusting {
 			a.updateAdjustment(mx, my)
+			if a.snapping() {
+				a.snapAdjusting()
+			}
 		}
 	} else {
 		// final do arrasto -> trava seleção (não salva)
@@ -221,6 +467,7 @@ func (a *App) Update() error {
 			if x1-x0 >= 2 && y1-y0 >= 2 {
 				a.selX0, a.selY0, a.selX1, a.selY1 = x0, y0, x1, y1
 				a.hasSelection = true
+				a.record(selectionCmd{to: a.selectionRect()})
 				a.infoMessage = "Seleção pronta. Use Salvar/Enter ou Cancelar/Esc."
 			} else {
 				a.infoMessage = "Seleção pequena. Tente novamente."
@@ -229,26 +476,38 @@ func (a *App) Update() error {
 		}
 		if a.adjusting {
 			a.adjusting = false
+			orig := image.Rect(a.origSelX0, a.origSelY0, a.origSelX1, a.origSelY1)
 			if a.selX1-a.selX0 >= 2 && a.selY1-a.selY0 >= 2 {
+				if cur := a.selectionRect(); cur != orig {
+					a.record(selectionCmd{from: orig, to: cur})
+				}
 				a.infoMessage = "Seleção ajustada. Use Salvar/Enter ou ajuste novamente."
 			} else {
+				a.record(clearCmd{sel: orig, annotations: append([]annotation(nil), a.annotations...)})
 				a.clearSelection()
 				a.infoMessage = "Seleção pequena. Tente novamente."
 			}
 		}
 	}
 
-	// Click nos botões (quando há seleção)
+	a.updateButtons(mx, my)
+	a.updateCursor(mx, my)
+
+	return nil
+}
+
+// updateButtons trata o clique nos botões (quando há seleção).
+func (a *App) updateButtons(mx, my int) {
 	if a.hasSelection && inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft) {
 		if a.saveBtn.Contains(mx, my) {
 			a.doSave()
 		} else if a.cancelBtn.Contains(mx, my) {
-			a.clearSelection()
+			a.cancelSelection()
 			a.infoMessage = "Seleção cancelada."
+		} else if a.copyBtn.Contains(mx, my) {
+			a.doCopy()
 		}
 	}
-
-	return nil
 }
 
 func (a *App) Draw(screen *ebiten.Image) {
@@ -261,16 +520,26 @@ func (a *App) Draw(screen *ebiten.Image) {
 		x0, y0, x1, y1 := normRect(a.startX, a.startY, a.curX, a.curY)
 		a.drawOverlayWithHole(screen, x0, y0, x1, y1)
 		drawRectBorder(screen, x0, y0, x1, y1)
+		a.drawSelectionReadout(screen, image.Rect(x0, y0, x1, y1))
 	}
 
 	// após finalizar seleção (travada), desenha overlay/borda e os botões
-	if a.hasSelection {
+	if a.hasSelection && !a.picking {
 		a.drawOverlayWithHole(screen, a.selX0, a.selY0, a.selX1, a.selY1)
 		drawRectBorder(screen, a.selX0, a.selY0, a.selX1, a.selY1)
+		a.drawAnnotationLayer(screen)
+		a.drawTextCaret(screen)
+		a.drawSelectionReadout(screen, a.selectionRect())
 
 		mx, my := ebiten.CursorPosition()
+		a.drawGrips(screen, a.selectionRect(), mx, my)
 		a.saveBtn.Draw(screen, a.saveBtn.Contains(mx, my))
 		a.cancelBtn.Draw(screen, a.cancelBtn.Contains(mx, my))
+		a.copyBtn.Draw(screen, a.copyBtn.Contains(mx, my))
+	}
+
+	if a.picking {
+		a.drawWindowPick(screen)
 	}
 
 	// mensagens
@@ -286,11 +555,46 @@ func (a *App) Draw(screen *ebiten.Image) {
 	if a.modeAll {
 		mode = "todos monitores"
 	}
-	dispInfo := fmt.Sprintf("Modo: %s | Monitor %d/%d", mode, a.curDisp+1, len(a.displays))
+	f, _ := lookupFormat(a.cfg.Format)
+	dispInfo := fmt.Sprintf("Modo: %s | Monitor %d/%d | Formato: %s | Seleção: %s", mode, a.curDisp+1, len(a.displays), f.Label(), a.constraint())
 	ebitenutil.DebugPrintAt(screen, dispInfo, 16, 56)
+	if a.tool.isRedaction() {
+		ebitenutil.DebugPrintAt(screen, "Tarja: "+a.tool.String(), 16, 76)
+	} else if a.tool == toolText {
+		pill := "não"
+		if a.textPill {
+			pill = "sim"
+		}
+		toolInfo := fmt.Sprintf("Texto | Tamanho: %d | Fundo: %s | Cor:", a.textSize, pill)
+		ebitenutil.DebugPrintAt(screen, toolInfo, 16, 76)
+		ebitenutil.DrawRect(screen, float64(16+utf8.RuneCountInString(toolInfo)*6+6), 78, 12, 12, a.annotationColor())
+	} else if a.tool != toolNone {
+		toolInfo := fmt.Sprintf("Ferramenta: %s | Espessura: %d | Cor:", a.tool, a.annoWidth)
+		ebitenutil.DebugPrintAt(screen, toolInfo, 16, 76)
+		ebitenutil.DrawRect(screen, float64(16+utf8.RuneCountInString(toolInfo)*6+6), 78, 12, 12, a.annotationColor())
+	}
+	if a.prompting {
+		a.drawScaledLabel(screen, "x,y,w,h: "+a.promptText+"_", 16, 96, max(a.viewScale, 1))
+	}
+
+	if a.capturing() {
+		scale := max(a.viewScale, 1)
+		x := float64(screen.Bounds().Dx())/2 - 80*scale
+		a.drawScaledLabel(screen, "capturando… (Esc cancela)", x, float64(screen.Bounds().Dy())/2, scale)
+	}
+
+	// lupa por cima de tudo
+	a.drawLoupe(screen)
+
+	if a.captureErr != nil {
+		a.drawCaptureError(screen)
+	}
 }
 
 func (a *App) Layout(outsideWidth, outsideHeight int) (int, int) {
+	if outsideWidth > 0 {
+		a.viewScale = float64(a.bg.Bounds().Dx()) / float64(outsideWidth)
+	}
 	return a.bg.Bounds().Dx(), a.bg.Bounds().Dy()
 }
 
@@ -340,7 +644,8 @@ func (a *App) drawOverlayWithHole(screen *ebiten.Image, x0, y0, x1, y1 int) {
 }
 
 func (a *App) selectionHandle(x, y int) int {
-	const tolerance = 10
+	// a tolerância é em pixels de tela: com a janela reduzida, cresce em rawBG
+	tolerance := int(handleTolerance * max(a.viewScale, 1))
 	nearX0 := abs(x-a.selX0) <= tolerance
 	nearX1 := abs(x-a.selX1) <= tolerance
 	nearY0 := abs(y-a.selY0) <= tolerance
@@ -373,36 +678,13 @@ func (a *App) selectionHandle(x, y int) int {
 func (a *App) updateAdjustment(x, y int) {
 	dx := x - a.adjustStartX
 	dy := y - a.adjustStartY
-	x0, y0, x1, y1 := a.origSelX0, a.origSelY0, a.origSelX1, a.origSelY1
-
-	switch a.adjustHandle {
-	case 1:
-		x0 += dx
-		y0 += dy
-	case 2:
-		x0 += dx
-		y1 += dy
-	case 3:
-		x1 += dx
-		y0 += dy
-	case 4:
-		x1 += dx
-		y1 += dy
-	case 5:
-		y0 += dy
-	case 6:
-		y1 += dy
-	case 7:
-		x0 += dx
-	case 8:
-		x1 += dx
-	case 9:
-		x0 += dx
-		x1 += dx
-		y0 += dy
-		y1 += dy
-	}
-	a.selX0, a.selY0, a.selX1, a.selY1 = normRect(x0, y0, x1, y1)
+	if c := a.constraint(); c.active() {
+		orig := image.Rect(a.origSelX0, a.origSelY0, a.origSelX1, a.origSelY1)
+		r := constrainHandle(a.adjustHandle, orig, dx, dy, c, a.rawBG.Bounds())
+		a.selX0, a.selY0, a.selX1, a.selY1 = r.Min.X, r.Min.Y, r.Max.X, r.Max.Y
+		return
+	}
+	a.selX0, a.selY0, a.selX1, a.selY1 = applyHandle(a.adjustHandle, a.origSelX0, a.origSelY0, a.origSelX1, a.origSelY1, dx, dy)
 }
 
 func abs(value int) int {
@@ -414,70 +696,14 @@ func abs(value int) int {
 
 // ---- captura / UI / salvar ----
 
-func captureDisplay(index int) *image.RGBA {
-	b := screenshot.GetDisplayBounds(index)
-	img, err := screenshot.CaptureRect(b)
-	if err != nil {
-		fmt.Println("Erro ao capturar display:", index, err)
-		dst := image.NewRGBA(image.Rect(0, 0, 800, 600))
-		return dst
-	}
-	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
-	draw.Draw(dst, dst.Bounds(), img, image.Point{}, draw.Src)
-	return dst
-}
-
-func captureAllDisplays() *image.RGBA {
-	n := screenshot.NumActiveDisplays()
-	if n <= 0 {
-		return nil
-	}
-	minX, minY := 1<<30, 1<<30
-	maxX, maxY := -1<<30, -1<<30
-	bounds := make([]image.Rectangle, 0, n)
-	for i := 0; i < n; i++ {
-		b := screenshot.GetDisplayBounds(i)
-		bounds = append(bounds, b)
-		if b.Min.X < minX {
-			minX = b.Min.X
-		}
-		if b.Min.Y < minY {
-			minY = b.Min.Y
-		}
-		if b.Max.X > maxX {
-			maxX = b.Max.X
-		}
-		if b.Max.Y > maxY {
-			maxY = b.Max.Y
-		}
-	}
-	total := image.Rect(0, 0, maxX-minX, maxY-minY)
-	dst := image.NewRGBA(total)
-	for i := 0; i < n; i++ {
-		b := bounds[i]
-		img, err := screenshot.CaptureRect(b)
-		if err != nil {
-			fmt.Println("Erro ao capturar display:", i, err)
-			continue
-		}
-		at := image.Pt(b.Min.X-minX, b.Min.Y-minY)
-		r := image.Rectangle{Min: at, Max: at.Add(img.Bounds().Size())}
-		draw.Draw(dst, r, img, image.Point{}, draw.Src)
-	}
-	return dst
-}
-
 func (a *App) switchDisplay(index int) {
 	if index < 0 || index >= len(a.displays) {
 		return
 	}
 	a.curDisp = index
 	a.clearSelection()
-	raw := captureDisplay(index)
-	a.rawBG = raw
-	a.bg = ebiten.NewImageFromImage(raw)
-	a.layoutButtons(raw.Bounds().Dx(), raw.Bounds().Dy())
-	a.infoMessage = "Monitor alterado. Arraste para selecionar. Enter=Salvar, Esc=Cancelar."
+	a.history = history{}
+	a.recapture(time.Now())
 }
 
 func (a *App) layoutButtons(w, h int) {
@@ -494,35 +720,133 @@ func (a *App) layoutButtons(w, h int) {
 		Label: "[Esc] Cancelar",
 		Hot:   'C',
 	}
+	a.copyBtn = Button{
+		Rect:  image.Rect(padding+2*(btnW+8), h-padding-btnH, padding+2*(btnW+8)+btnW, h-padding),
+		Label: "[Ctrl+C] Copiar",
+	}
 }
 
 func (a *App) doSave() {
 	if !a.hasSelection {
 		return
 	}
-	rect := image.Rect(a.selX0, a.selY0, a.selX1, a.selY1)
-	sub := a.rawBG.SubImage(rect)
+	a.finishTextEdit()
+	rect := a.selectionRect()
+	sub := a.selectionImage()
+
+	format, err := lookupFormat(a.cfg.Format)
+	if err != nil {
+		a.infoMessage = "Erro ao codificar: " + err.Error()
+		return
+	}
 
-	dir := picturesDir()
+	dir := outputDir(a.cfg.Dir)
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		a.infoMessage = "Falha ao criar diretório de saída: " + err.Error()
 		return
 	}
-	name := "snip-" + time.Now().Format("20060102-150405") + ".png"
-	path := filepath.Join(dir, name)
+	vars := newNameVars(time.Now(), a.displayName(), rect.Dx(), rect.Dy())
+	path := filepath.Join(dir, fileName(dir, a.cfg.NameTemplate, vars, format.Ext))
 
-	var buf bytes.Buffer
-	if err := png.Encode(&buf, sub); err != nil {
-		a.infoMessage = "Erro ao codificar PNG: " + err.Error()
+	data, err := encodeImage(sub, format, a.cfg.encodeOptions())
+	if err != nil {
+		a.infoMessage = "Erro ao codificar " + format.Label() + ": " + err.Error()
 		return
 	}
-	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+	path, err = writeFileUnique(path, data)
+	if err != nil {
 		a.infoMessage = "Erro ao salvar arquivo: " + err.Error()
 		return
 	}
 	a.savedPath = path
 	a.infoMessage = "Imagem salva! " + path
-	a.clearSelection() // limpa após salvar
+	a.cancelSelection() // limpa após salvar (Ctrl+Z recupera a seleção)
+}
+
+// doCopy copia a seleção (PNG) para a área de transferência. A seleção é
+// mantida para permitir salvar em seguida.
+func (a *App) doCopy() {
+	if !a.hasSelection {
+		return
+	}
+	a.finishTextEdit()
+	if a.clipboard == nil {
+		a.infoMessage = "Área de transferência indisponível."
+		return
+	}
+	format, _ := lookupFormat("png")
+	data, err := encodeImage(a.selectionImage(), format, a.cfg.encodeOptions())
+	if err != nil {
+		a.infoMessage = "Erro ao codificar: " + err.Error()
+		return
+	}
+	if err := a.clipboard.SetImage(data); err != nil {
+		a.infoMessage = "Erro ao copiar: " + err.Error()
+		return
+	}
+	a.infoMessage = "Seleção copiada para a área de transferência."
+}
+
+func (a *App) selectionRect() image.Rectangle {
+	return image.Rect(a.selX0, a.selY0, a.selX1, a.selY1)
+}
+
+// selectionImage é o recorte exportado (salvar e copiar), com as anotações
+// já rasterizadas. A origem da imagem é (0,0).
+func (a *App) selectionImage() *image.RGBA {
+	rect := a.selectionRect()
+	out := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(out, out.Bounds(), a.rawBG, rect.Min, draw.Src)
+	drawAnnotations(out, a.annotations, rect.Min)
+	return out
+}
+
+func isCtrlPressed() bool {
+	return ebiten.IsKeyPressed(ebiten.KeyControl) || ebiten.IsKeyPressed(ebiten.KeyMeta)
+}
+
+// displayName identifica o monitor atual no template de nome.
+func (a *App) displayName() string {
+	if a.modeAll {
+		return "all"
+	}
+	return strconv.Itoa(a.curDisp)
+}
+
+// parseGUIFlags aplica as opções de linha de comando do modo interativo.
+func parseGUIFlags(cfg *Config, args []string) (startDelay int, err error) {
+	fs := flag.NewFlagSet("go-screentake", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), cliUsage, "\nOpções do modo interativo:\n")
+		fs.PrintDefaults()
+	}
+	format := fs.String("format", cfg.Format, "formato de saída: png, jpeg, gif, bmp, tiff")
+	fs.IntVar(&cfg.Quality, "quality", cfg.Quality, "qualidade JPEG (1-100)")
+	fs.StringVar(&cfg.Dir, "dir", cfg.Dir, "diretório de saída (padrão: diretório de imagens do usuário)")
+	fs.StringVar(&cfg.NameTemplate, "name", cfg.NameTemplate, "template do nome: {date} {time} {display} {w} {h} {seq} {hostname}")
+	delay := fs.Int("delay", 0, "espera N segundos (janela minimizada) antes da captura inicial; também passa a valer para a tecla D")
+	if err := fs.Parse(args); err != nil {
+		return 0, err
+	}
+	f, err := lookupFormat(*format)
+	if err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		return 0, err
+	}
+	cfg.Format = f.Name
+	if err := checkQuality(cfg.Quality); err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		return 0, err
+	}
+	if *delay < 0 {
+		err := errors.New("--delay não pode ser negativo")
+		fmt.Fprintln(fs.Output(), err)
+		return 0, err
+	}
+	if *delay > 0 {
+		cfg.Delay = *delay
+	}
+	return *delay, nil
 }
 
 func (a *App) clearSelection() {
@@ -533,18 +857,11 @@ func (a *App) clearSelection() {
 	a.startX, a.startY = 0, 0
 	a.curX, a.curY = 0, 0
 	a.selX0, a.selY0, a.selX1, a.selY1 = 0, 0, 0, 0
-}
-
-func picturesDir() string {
-	home, _ := os.UserHomeDir()
-	switch runtime.GOOS {
-	case "darwin":
-		return filepath.Join(home, "Pictures")
-	case "windows":
-		return filepath.Join(home, "Pictures")
-	default:
-		return filepath.Join(home, "Pictures")
-	}
+	a.tool = toolNone
+	a.drawing = nil
+	a.editing = false
+	a.annotations = nil
+	a.annoDirty = true
 }
 
 func min(a, b int) int {
@@ -63,10 +63,26 @@ func TestNormRect(t *testing.T) {
 }
 
 func TestPicturesDir(t *testing.T) {
+	home := t.TempDir()
+	isolatePictures(t, home)
 	dir := picturesDir()
 	if filepath.Base(dir) != "Pictures" {
 		t.Fatalf("picturesDir base = %q; want 'Pictures' (GOOS=%s)", filepath.Base(dir), runtime.GOOS)
 	}
+	if runtime.GOOS != "linux" {
+		return
+	}
+	// diretório localizado do xdg-user-dirs
+	cfgDir := filepath.Join(home, ".config")
+	if err := os.MkdirAll(cfgDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cfgDir, "user-dirs.dirs"), []byte(`XDG_PICTURES_DIR="$HOME/Imagens"`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := picturesDir(), filepath.Join(home, "Imagens"); got != want {
+		t.Fatalf("picturesDir = %q; want %q", got, want)
+	}
 }
 
 func TestClearSelection(t *testing.T) {
@@ -118,6 +134,7 @@ func TestDoSaveWritesPNG(t *testing.T) {
 	} else {
 		t.Cleanup(func() { _ = os.Unsetenv("HOME") })
 	}
+	isolatePictures(t, tmp)
 
 	// cria imagem base 50x50 com uma cor sólida
 	raw := image.NewRGBA(image.Rect(0, 0, 50, 50))
@@ -185,6 +202,7 @@ func TestDoSaveFailsWhenPicturesIsAFile(t *testing.T) {
 	} else {
 		t.Cleanup(func() { _ = os.Unsetenv("HOME") })
 	}
+	isolatePictures(t, tmp)
 
 	// cria um arquivo no caminho onde deveria ser o diretório Pictures
 	picsPath := filepath.Join(tmp, "Pictures")
@@ -219,6 +237,7 @@ func TestDoSaveFailsOnUnwritableDir(t *testing.T) {
 	} else {
 		t.Cleanup(func() { _ = os.Unsetenv("HOME") })
 	}
+	isolatePictures(t, tmp)
 
 	picsDir := filepath.Join(tmp, "Pictures")
 	if err := os.MkdirAll(picsDir, 0o555); err != nil { // sem permissão de escrita
@@ -252,6 +271,7 @@ func TestDoSaveWithoutSelection(t *testing.T) {
 	} else {
 		t.Cleanup(func() { _ = os.Unsetenv("HOME") })
 	}
+	isolatePictures(t, tmp)
 
 	raw := image.NewRGBA(image.Rect(0, 0, 20, 20))
 	app := &App{rawBG: raw, infoMessage: "keep"}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultNameTemplate mantém o nome histórico snip-YYYYMMDD-HHMMSS.
+const defaultNameTemplate = "snip-{date}-{time}"
+
+// nameVars são os valores disponíveis no template de nome de arquivo.
+type nameVars struct {
+	Time    time.Time
+	Display string // índice do monitor (começa em 0) ou "all"
+	W, H    int
+	Host    string
+}
+
+func newNameVars(t time.Time, display string, w, h int) nameVars {
+	host, _ := os.Hostname()
+	return nameVars{Time: t, Display: display, W: w, H: h, Host: host}
+}
+
+// expandTemplate substitui {date}, {time}, {display}, {w}, {h}, {seq} e
+// {hostname}. Placeholders desconhecidos ficam como estão.
+func expandTemplate(tmpl string, v nameVars, seq int) string {
+	r := strings.NewReplacer(
+		"{date}", v.Time.Format("20060102"),
+		"{time}", v.Time.Format("150405"),
+		"{display}", sanitizeName(v.Display),
+		"{w}", strconv.Itoa(v.W),
+		"{h}", strconv.Itoa(v.H),
+		"{seq}", fmt.Sprintf("%03d", seq),
+		"{hostname}", sanitizeName(v.Host),
+	)
+	return sanitizeName(r.Replace(tmpl))
+}
+
+// sanitizeName remove separadores de caminho e caracteres problemáticos.
+func sanitizeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// fileName expande o template para um nome dentro de dir. Com {seq}, usa o
+// menor número (a partir de 1) que ainda não existe no diretório.
+func fileName(dir, tmpl string, v nameVars, ext string) string {
+	if tmpl == "" {
+		tmpl = defaultNameTemplate
+	}
+	if !strings.Contains(tmpl, "{seq}") {
+		return expandTemplate(tmpl, v, 0) + ext
+	}
+	for seq := 1; ; seq++ {
+		name := expandTemplate(tmpl, v, seq) + ext
+		if _, err := os.Lstat(filepath.Join(dir, name)); errors.Is(err, fs.ErrNotExist) {
+			return name
+		}
+	}
+}
+
+// outputDir resolve o diretório de saída: o configurado (aceita ~ e
+// variáveis de ambiente) ou o diretório de imagens do usuário.
+func outputDir(configured string) string {
+	if configured == "" {
+		return picturesDir()
+	}
+	p := os.ExpandEnv(configured)
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		home, _ := os.UserHomeDir()
+		p = filepath.Join(home, p[1:])
+	}
+	return p
+}
+
+// picturesDir retorna o diretório de imagens do usuário. No Linux respeita
+// XDG_PICTURES_DIR (ambiente ou user-dirs.dirs); nos demais, ~/Pictures.
+func picturesDir() string {
+	home, _ := os.UserHomeDir()
+	if runtime.GOOS == "linux" {
+		if dir := xdgPicturesDir(home); dir != "" {
+			return dir
+		}
+	}
+	return filepath.Join(home, "Pictures")
+}
+
+func xdgPicturesDir(home string) string {
+	if dir := os.Getenv("XDG_PICTURES_DIR"); dir != "" {
+		return dir
+	}
+	cfgHome := os.Getenv("XDG_CONFIG_HOME")
+	if cfgHome == "" {
+		cfgHome = filepath.Join(home, ".config")
+	}
+	f, err := os.Open(filepath.Join(cfgHome, "user-dirs.dirs"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	return parseUserDirs(f, home)
+}
+
+// parseUserDirs lê XDG_PICTURES_DIR="$HOME/..." no formato do xdg-user-dirs.
+func parseUserDirs(r io.Reader, home string) string {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		value, ok := strings.CutPrefix(line, "XDG_PICTURES_DIR=")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(value, `"`)
+		if rest, ok := strings.CutPrefix(value, "$HOME"); ok {
+			value = home + rest
+		}
+		// "$HOME/" sozinho significa "desabilitado" pela especificação
+		if !filepath.IsAbs(value) || filepath.Clean(value) == filepath.Clean(home) {
+			return ""
+		}
+		return value
+	}
+	return ""
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	v := nameVars{
+		Time:    time.Date(2025, 8, 24, 13, 5, 9, 0, time.UTC),
+		Display: "1",
+		W:       1280, H: 720,
+		Host: "box/01",
+	}
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{defaultNameTemplate, "snip-20250824-130509"},
+		{"{hostname}_{display}_{w}x{h}", "box_01_1_1280x720"},
+		{"shot-{seq}", "shot-007"},
+		{"{unknown}-{date}", "{unknown}-20250824"},
+		{"../{date}", ".._20250824"},
+	}
+	for _, tc := range tests {
+		if got := expandTemplate(tc.tmpl, v, 7); got != tc.want {
+			t.Fatalf("expandTemplate(%q) = %q; want %q", tc.tmpl, got, tc.want)
+		}
+	}
+}
+
+func TestFileNameSequence(t *testing.T) {
+	dir := t.TempDir()
+	v := nameVars{Time: time.Now(), Display: "0", W: 10, H: 10}
+
+	for i, want := range []string{"proj-001.png", "proj-002.png", "proj-003.png"} {
+		got := fileName(dir, "proj-{seq}", v, ".png")
+		if got != want {
+			t.Fatalf("iteração %d: got %q want %q", i, got, want)
+		}
+		if err := os.WriteFile(filepath.Join(dir, got), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := fileName(dir, "", v, ".png"); !strings.HasPrefix(got, "snip-") {
+		t.Fatalf("template vazio deveria usar o padrão: %q", got)
+	}
+}
+
+func TestParseUserDirs(t *testing.T) {
+	home := "/home/ana"
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"# comentário\nXDG_DESKTOP_DIR=\"$HOME/Área de trabalho\"\nXDG_PICTURES_DIR=\"$HOME/Imagens\"\n", "/home/ana/Imagens"},
+		{"XDG_PICTURES_DIR=\"/srv/shots\"\n", "/srv/shots"},
+		{"XDG_PICTURES_DIR=\"$HOME/\"\n", ""},
+		{"XDG_MUSIC_DIR=\"$HOME/Música\"\n", ""},
+	}
+	for _, tc := range tests {
+		if got := parseUserDirs(strings.NewReader(tc.in), home); got != tc.want {
+			t.Fatalf("parseUserDirs(%q) = %q; want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+// isolatePictures aponta HOME para home e neutraliza as variáveis XDG, para
+// picturesDir não depender do (nem gravar no) diretório de imagens real.
+func isolatePictures(t *testing.T, home string) {
+	t.Helper()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("XDG_PICTURES_DIR", "")
+}
+
+func TestOutputDir(t *testing.T) {
+	home := t.TempDir()
+	isolatePictures(t, home)
+	t.Setenv("GST_TEST_PROJ", "alpha")
+
+	if got := outputDir("~/shots/$GST_TEST_PROJ"); got != filepath.Join(home, "shots", "alpha") {
+		t.Fatalf("outputDir expandido incorretamente: %q", got)
+	}
+	if got := outputDir(""); got != picturesDir() {
+		t.Fatalf("outputDir vazio deveria usar picturesDir: %q", got)
+	}
+}
+
+func TestDoSaveUsesDirAndTemplate(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "proj")
+	raw := sampleImage(20, 20, false)
+	app := &App{rawBG: raw, curDisp: 1, cfg: Config{Dir: out, NameTemplate: "{display}-{w}x{h}-{seq}"}}
+	app.selX0, app.selY0, app.selX1, app.selY1 = 2, 2, 12, 7
+	app.hasSelection = true
+	app.doSave()
+	if want := filepath.Join(out, "1-10x5-001.png"); app.savedPath != want {
+		t.Fatalf("savedPath = %q; want %q (info=%q)", app.savedPath, want, app.infoMessage)
+	}
+}
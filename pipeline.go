@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"image"
+	"sync"
+)
+
+// captureResult é o que uma captura em segundo plano devolve ao loop do
+// jogo: a imagem e a lista de janelas tirada junto com ela, para a escolha
+// de janela (W) corresponder ao que está na imagem.
+type captureResult struct {
+	id         uint64
+	img        *image.RGBA
+	err        error
+	windows    []windowInfo
+	windowsErr error
+}
+
+// captureFunc executa uma captura (o id é preenchido pelo pipeline); deve
+// desistir cedo se ctx for cancelado.
+type captureFunc func(ctx context.Context) captureResult
+
+// capturePipeline tira as capturas do loop do jogo. Cada pedido recebe um id
+// crescente e cancela o anterior; poll só entrega o resultado do pedido mais
+// recente, então uma captura lenta que termina depois de uma troca de
+// monitor nunca sobrescreve a imagem nova.
+type capturePipeline struct {
+	mu      sync.Mutex
+	seq     uint64 // id do último pedido
+	done    uint64 // id do último resultado entregue (ou cancelado)
+	cancel  context.CancelFunc
+	results chan captureResult
+}
+
+func newCapturePipeline() *capturePipeline {
+	return &capturePipeline{results: make(chan captureResult, 1)}
+}
+
+// request cancela o pedido em curso (se houver) e inicia fn em uma
+// goroutine. Devolve o id do novo pedido.
+func (p *capturePipeline) request(fn captureFunc) uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cancel != nil {
+		p.cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.seq++
+	id := p.seq
+	go func() {
+		r := fn(ctx)
+		if ctx.Err() != nil {
+			return // cancelado: ninguém espera mais este resultado
+		}
+		r.id = id
+		select {
+		case p.results <- r:
+		case <-ctx.Done():
+		}
+	}()
+	return id
+}
+
+// poll devolve, sem bloquear, o resultado do pedido mais recente quando ele
+// chega. Resultados de pedidos antigos são descartados.
+func (p *capturePipeline) poll() (captureResult, bool) {
+	for {
+		select {
+		case r := <-p.results:
+			p.mu.Lock()
+			current := r.id == p.seq && p.done < r.id
+			if current {
+				p.done = r.id
+				p.cancel() // libera o contexto do pedido concluído
+				p.cancel = nil
+			}
+			p.mu.Unlock()
+			if current {
+				return r, true
+			}
+		default:
+			return captureResult{}, false
+		}
+	}
+}
+
+// cancelPending abandona o pedido em curso; o resultado dele, se chegar, é
+// descartado.
+func (p *capturePipeline) cancelPending() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+	p.done = p.seq
+}
+
+// busy informa se há um pedido aguardando resultado.
+func (p *capturePipeline) busy() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.done != p.seq
+}
+
+// captureMode captura um monitor ou o desktop inteiro, respeitando o
+// cancelamento antes de começar e, no modo todos, entre um display e outro
+// (por isso usa composeDisplays e não c.CaptureAll). A chamada ao backend
+// em si não é interrompível: uma captura cancelada no meio termina e o
+// resultado é descartado pelo pipeline.
+func captureMode(ctx context.Context, c Capturer, all bool, disp int) (*image.RGBA, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if all {
+		return composeDisplays(ctx, c, c.Displays())
+	}
+	return captureDisplay(c, disp)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"image"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowCapturer segura cada captura até release fechar (ou para sempre).
+type slowCapturer struct {
+	*fakeCapturer
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (s *slowCapturer) CaptureRect(r image.Rectangle) (*image.RGBA, error) {
+	s.calls.Add(1)
+	<-s.release
+	return s.fakeCapturer.CaptureRect(r)
+}
+
+func (s *slowCapturer) CaptureAll() (*image.RGBA, error) {
+	s.calls.Add(1)
+	<-s.release
+	return s.fakeCapturer.CaptureAll()
+}
+
+func waitResult(t *testing.T, p *capturePipeline) captureResult {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if r, ok := p.poll(); ok {
+			return r
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("resultado não chegou")
+	return captureResult{}
+}
+
+func TestPipelineDropsStaleResults(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	slow := &slowCapturer{fakeCapturer: newFakeCapturer(desktop, displays...), release: make(chan struct{})}
+	fast := newFakeCapturer(desktop, displays...)
+	p := newCapturePipeline()
+
+	first := p.request(func(ctx context.Context) captureResult {
+		img, err := captureMode(ctx, slow, false, 0)
+		return captureResult{img: img, err: err}
+	})
+	for slow.calls.Load() == 0 {
+		time.Sleep(time.Millisecond) // a primeira está de fato no backend
+	}
+	second := p.request(func(ctx context.Context) captureResult {
+		img, err := captureMode(ctx, fast, false, 1)
+		return captureResult{img: img, err: err}
+	})
+	if second <= first {
+		t.Fatalf("ids deveriam crescer: %d, %d", first, second)
+	}
+
+	r := waitResult(t, p)
+	if r.id != second || r.err != nil || r.img.Bounds().Dx() != 60 {
+		t.Fatalf("esperava o resultado do segundo pedido: %+v", r)
+	}
+	close(slow.release) // a lenta termina depois: deve sumir
+	time.Sleep(20 * time.Millisecond)
+	if r, ok := p.poll(); ok {
+		t.Fatalf("resultado obsoleto entregue: id %d", r.id)
+	}
+	if p.busy() {
+		t.Fatalf("pipeline deveria estar livre")
+	}
+}
+
+func TestPipelineCancelPending(t *testing.T) {
+	p := newCapturePipeline()
+	stopped := make(chan struct{})
+	p.request(func(ctx context.Context) captureResult {
+		<-ctx.Done()
+		close(stopped)
+		return captureResult{err: ctx.Err()}
+	})
+	if !p.busy() {
+		t.Fatalf("pedido em curso deveria deixar o pipeline ocupado")
+	}
+	p.cancelPending()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("o contexto do pedido não foi cancelado")
+	}
+	if p.busy() {
+		t.Fatalf("cancelado: nada mais a esperar")
+	}
+	if _, ok := p.poll(); ok {
+		t.Fatalf("pedido cancelado não entrega resultado")
+	}
+}
+
+func TestCaptureModeHonorsCanceledContext(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	slow := &slowCapturer{fakeCapturer: newFakeCapturer(desktop, displays...), release: make(chan struct{})}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := captureMode(ctx, slow, true, 0); err == nil {
+		t.Fatalf("contexto cancelado deveria falhar")
+	}
+	if slow.calls.Load() != 0 {
+		t.Fatalf("não deveria chamar o backend depois de cancelado")
+	}
+}
+
+func TestCaptureModeCanceledDuringBackendCall(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	slow := &slowCapturer{fakeCapturer: newFakeCapturer(desktop, displays...), release: make(chan struct{})}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		img, err := captureMode(ctx, slow, true, 0)
+		if img != nil {
+			err = nil
+		}
+		done <- err
+	}()
+	for slow.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	close(slow.release) // o backend termina sozinho
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("cancelado no meio deveria devolver context.Canceled sem imagem: %v", err)
+	}
+}
+
+func TestAppCaptureDoesNotBlockUpdate(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	slow := &slowCapturer{fakeCapturer: newFakeCapturer(desktop, displays...), release: make(chan struct{})}
+	app := &App{capturer: slow, displays: displays, rawBG: blankCanvas(60, 40)}
+
+	app.startCapture() // volta na hora, mesmo com o backend travado
+	if !app.capturing() {
+		t.Fatalf("deveria estar capturando")
+	}
+	app.pollCapture()
+	if app.rawBG.Bounds().Dx() != 60 || !app.capturing() {
+		t.Fatalf("nada deveria mudar antes do resultado")
+	}
+	close(slow.release)
+	waitCapture(t, app)
+	rectEq(t, app.rawBG.Bounds(), image.Rect(0, 0, 40, 30))
+}
+
+func TestPipelineReleasesDeliveredContext(t *testing.T) {
+	p := newCapturePipeline()
+	ctxs := make(chan context.Context, 1)
+	p.request(func(ctx context.Context) captureResult {
+		ctxs <- ctx
+		return captureResult{img: image.NewRGBA(image.Rect(0, 0, 1, 1))}
+	})
+	waitResult(t, p)
+	if err := (<-ctxs).Err(); err == nil {
+		t.Fatalf("o contexto do pedido entregue deveria ser cancelado")
+	}
+}
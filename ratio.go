@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// selectionConstraint restringe o retângulo arrastado a uma proporção
+// (RatioW:RatioH) ou a um tamanho fixo em pixels. Zero = livre.
+type selectionConstraint struct {
+	RatioW, RatioH int
+	FixedW, FixedH int
+}
+
+func (c selectionConstraint) active() bool {
+	return c.fixed() || (c.RatioW > 0 && c.RatioH > 0)
+}
+
+func (c selectionConstraint) fixed() bool {
+	return c.FixedW > 0 && c.FixedH > 0
+}
+
+// fits informa se um tamanho fixo cabe inteiro em bounds (proporções sempre
+// cabem).
+func (c selectionConstraint) fits(bounds image.Rectangle) bool {
+	return !c.fixed() || (c.FixedW <= bounds.Dx() && c.FixedH <= bounds.Dy())
+}
+
+func (c selectionConstraint) String() string {
+	switch {
+	case c.fixed():
+		return fmt.Sprintf("%d×%d", c.FixedW, c.FixedH)
+	case c.active():
+		return fmt.Sprintf("%d:%d", c.RatioW, c.RatioH)
+	}
+	return "livre"
+}
+
+// ratioPresets é o ciclo da tecla R (Shift durante o arrasto força 1:1).
+var ratioPresets = []selectionConstraint{
+	{},
+	{RatioW: 16, RatioH: 9},
+	{RatioW: 4, RatioH: 3},
+	{RatioW: 1, RatioH: 1},
+}
+
+var defaultSizePresets = []string{"1280x720", "1920x1080", "1080x1080"}
+
+// parseSize lê "LxA" (também aceita "×").
+func parseSize(s string) (int, int, error) {
+	s = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), "×", "x")
+	ws, hs, ok := strings.Cut(s, "x")
+	if !ok {
+		return 0, 0, fmt.Errorf("tamanho %q: esperado LARGURAxALTURA", s)
+	}
+	w, errW := strconv.Atoi(strings.TrimSpace(ws))
+	h, errH := strconv.Atoi(strings.TrimSpace(hs))
+	if errW != nil || errH != nil || w <= 0 || h <= 0 {
+		return 0, 0, fmt.Errorf("tamanho %q inválido", s)
+	}
+	return w, h, nil
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// constrainCorner devolve o canto móvel de um retângulo ancorado em anchor
+// que segue o cursor p respeitando c e cabendo em bounds. Com proporção, o
+// tamanho é múltiplo inteiro da razão reduzida (16:9 → 16×9, 32×18, ...),
+// então a proporção do arquivo salvo é exata.
+func constrainCorner(anchor, p image.Point, c selectionConstraint, bounds image.Rectangle) image.Point {
+	if !c.active() {
+		return p
+	}
+	sx, sy := 1, 1
+	if p.X < anchor.X {
+		sx = -1
+	}
+	if p.Y < anchor.Y {
+		sy = -1
+	}
+	room := func(s, a, lo, hi int) int {
+		if s > 0 {
+			return hi - a
+		}
+		return a - lo
+	}
+	roomW := room(sx, anchor.X, bounds.Min.X, bounds.Max.X)
+	roomH := room(sy, anchor.Y, bounds.Min.Y, bounds.Max.Y)
+
+	if c.fixed() {
+		// sem espaço na direção do arrasto, vira para o outro lado
+		if c.FixedW > roomW && c.FixedW <= room(-sx, anchor.X, bounds.Min.X, bounds.Max.X) {
+			sx = -sx
+			roomW = room(sx, anchor.X, bounds.Min.X, bounds.Max.X)
+		}
+		if c.FixedH > roomH && c.FixedH <= room(-sy, anchor.Y, bounds.Min.Y, bounds.Max.Y) {
+			sy = -sy
+			roomH = room(sy, anchor.Y, bounds.Min.Y, bounds.Max.Y)
+		}
+		w, h := min(c.FixedW, roomW), min(c.FixedH, roomH)
+		return image.Pt(anchor.X+sx*w, anchor.Y+sy*h)
+	}
+
+	g := gcd(c.RatioW, c.RatioH)
+	uw, uh := c.RatioW/g, c.RatioH/g
+	dx, dy := abs(p.X-anchor.X), abs(p.Y-anchor.Y)
+	// o menor múltiplo que cobre o cursor, limitado ao espaço disponível
+	k := max((dx+uw-1)/uw, (dy+uh-1)/uh)
+	k = min(k, min(roomW/uw, roomH/uh))
+	return image.Pt(anchor.X+sx*k*uw, anchor.Y+sy*k*uh)
+}
+
+// constrainHandle é a versão restrita de applyHandle para o ajuste com o
+// mouse: cantos mantêm o canto oposto fixo, bordas derivam a outra dimensão.
+// Com tamanho fixo qualquer alça apenas move a seleção.
+func constrainHandle(handle int, orig image.Rectangle, dx, dy int, c selectionConstraint, bounds image.Rectangle) image.Rectangle {
+	if handle == 9 || c.fixed() {
+		x0, y0, x1, y1 := applyHandle(9, orig.Min.X, orig.Min.Y, orig.Max.X, orig.Max.Y, dx, dy)
+		return image.Rect(x0, y0, x1, y1)
+	}
+	var anchor, p image.Point
+	switch handle {
+	case 1:
+		anchor, p = orig.Max, orig.Min.Add(image.Pt(dx, dy))
+	case 2:
+		anchor, p = image.Pt(orig.Max.X, orig.Min.Y), image.Pt(orig.Min.X+dx, orig.Max.Y+dy)
+	case 3:
+		anchor, p = image.Pt(orig.Min.X, orig.Max.Y), image.Pt(orig.Max.X+dx, orig.Min.Y+dy)
+	case 4:
+		anchor, p = orig.Min, orig.Max.Add(image.Pt(dx, dy))
+	case 5: // topo: a base fica fixa, a largura acompanha a partir da esquerda
+		anchor = image.Pt(orig.Min.X, orig.Max.Y)
+		p = image.Pt(orig.Min.X+1, orig.Min.Y+dy)
+	case 6:
+		anchor = orig.Min
+		p = image.Pt(orig.Min.X+1, orig.Max.Y+dy)
+	case 7: // esquerda: a borda direita fica fixa, a altura cresce para baixo
+		anchor = image.Pt(orig.Max.X, orig.Min.Y)
+		p = image.Pt(orig.Min.X+dx, orig.Min.Y+1)
+	case 8:
+		anchor = orig.Min
+		p = image.Pt(orig.Max.X+dx, orig.Min.Y+1)
+	default:
+		return orig
+	}
+	q := constrainCorner(anchor, p, c, bounds)
+	x0, y0, x1, y1 := normRect(anchor.X, anchor.Y, q.X, q.Y)
+	return image.Rect(x0, y0, x1, y1)
+}
+
+// ---- estado na UI ----
+
+// constraint combina o modo escolhido (R/P) com Shift (quadrado).
+func (a *App) constraint() selectionConstraint {
+	if a.sizeIdx > 0 && a.sizeIdx <= len(a.sizePresets) {
+		return a.sizePresets[a.sizeIdx-1]
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyShift) {
+		return selectionConstraint{RatioW: 1, RatioH: 1}
+	}
+	return ratioPresets[a.ratioIdx%len(ratioPresets)]
+}
+
+// constrainedCursor aplica a restrição ao canto móvel durante a seleção.
+func (a *App) constrainedCursor(mx, my int) (int, int) {
+	p := constrainCorner(image.Pt(a.startX, a.startY), image.Pt(mx, my), a.constraint(), a.rawBG.Bounds())
+	return p.X, p.Y
+}
+
+// loadSizePresets converte os tamanhos da configuração (inválidos já foram
+// rejeitados por loadConfig).
+func loadSizePresets(specs []string) []selectionConstraint {
+	var out []selectionConstraint
+	for _, s := range specs {
+		if w, h, err := parseSize(s); err == nil {
+			out = append(out, selectionConstraint{FixedW: w, FixedH: h})
+		}
+	}
+	return out
+}
+
+// cycleRatio (R) troca a proporção e ajusta a seleção atual a ela.
+func (a *App) cycleRatio() {
+	a.sizeIdx = 0
+	a.ratioIdx = (a.ratioIdx + 1) % len(ratioPresets)
+	a.infoMessage = "Proporção: " + ratioPresets[a.ratioIdx].String()
+	a.applyConstraintToSelection()
+}
+
+// cycleSize (P) percorre os tamanhos fixos configurados (e "desligado").
+// Tamanhos maiores que a captura são recusados (pulados com aviso): o
+// recurso existe para garantir o tamanho exato do arquivo.
+func (a *App) cycleSize() {
+	if len(a.sizePresets) == 0 {
+		a.infoMessage = "Nenhum tamanho fixo configurado (size_presets)."
+		return
+	}
+	bounds := a.rawBG.Bounds()
+	var refused []string
+	for {
+		a.sizeIdx = (a.sizeIdx + 1) % (len(a.sizePresets) + 1)
+		if a.sizeIdx == 0 || a.sizePresets[a.sizeIdx-1].fits(bounds) {
+			break
+		}
+		refused = append(refused, a.sizePresets[a.sizeIdx-1].String())
+	}
+	if a.sizeIdx == 0 {
+		a.infoMessage = "Tamanho fixo desligado."
+	} else {
+		a.infoMessage = "Tamanho fixo: " + a.sizePresets[a.sizeIdx-1].String()
+	}
+	if len(refused) > 0 {
+		a.infoMessage += fmt.Sprintf(" (%s não cabe na captura %d×%d)", strings.Join(refused, ", "), bounds.Dx(), bounds.Dy())
+	}
+	a.applyConstraintToSelection()
+}
+
+// dropOversizedPreset desliga o tamanho fixo quando uma captura nova é
+// menor que ele.
+func (a *App) dropOversizedPreset() {
+	c := a.constraint()
+	if !c.fixed() || c.fits(a.rawBG.Bounds()) {
+		return
+	}
+	a.sizeIdx = 0
+	a.infoMessage = fmt.Sprintf("Tamanho fixo %s desligado: não cabe na captura %d×%d.", c, a.rawBG.Bounds().Dx(), a.rawBG.Bounds().Dy())
+}
+
+// applyConstraintToSelection reajusta a seleção travada mantendo o canto
+// superior esquerdo. Um tamanho fixo é aplicado exato: se passar da imagem,
+// a seleção é empurrada para dentro em vez de encolher.
+func (a *App) applyConstraintToSelection() {
+	c := a.constraint()
+	if !a.hasSelection || !c.active() {
+		return
+	}
+	from := a.selectionRect()
+	bounds := a.rawBG.Bounds()
+	var to image.Rectangle
+	if c.fixed() {
+		to = image.Rectangle{Min: from.Min, Max: from.Min.Add(image.Pt(c.FixedW, c.FixedH))}
+		to = to.Sub(image.Pt(max(0, to.Max.X-bounds.Max.X), max(0, to.Max.Y-bounds.Max.Y)))
+	} else {
+		q := constrainCorner(from.Min, from.Max, c, bounds)
+		to = image.Rectangle{Min: from.Min, Max: q}.Canon()
+	}
+	if to.Dx() < 2 || to.Dy() < 2 || to == from {
+		return
+	}
+	a.setSelection(to)
+	a.record(selectionCmd{from: from, to: to})
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"image"
+	"strings"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	for in, want := range map[string][2]int{"1280x720": {1280, 720}, "1080×1080": {1080, 1080}, " 64X32 ": {64, 32}} {
+		w, h, err := parseSize(in)
+		if err != nil || w != want[0] || h != want[1] {
+			t.Fatalf("parseSize(%q) = %d,%d,%v", in, w, h, err)
+		}
+	}
+	for _, bad := range []string{"", "1280", "0x10", "ax10", "-5x5"} {
+		if _, _, err := parseSize(bad); err == nil {
+			t.Fatalf("parseSize(%q) deveria falhar", bad)
+		}
+	}
+}
+
+func TestConstrainCornerRatio(t *testing.T) {
+	bounds := image.Rect(0, 0, 2000, 1200)
+	wide := selectionConstraint{RatioW: 16, RatioH: 9}
+
+	// cobre o cursor com múltiplo exato de 16×9
+	q := constrainCorner(image.Pt(100, 100), image.Pt(1370, 500), wide, bounds)
+	r := image.Rectangle{Min: image.Pt(100, 100), Max: q}
+	if r.Dx()*9 != r.Dy()*16 || r.Dx() < 1270 {
+		t.Fatalf("proporção: %v (%dx%d)", r, r.Dx(), r.Dy())
+	}
+	// 1280x720 exato é atingível
+	q = constrainCorner(image.Pt(0, 0), image.Pt(1280, 700), wide, bounds)
+	if q != image.Pt(1280, 720) {
+		t.Fatalf("esperava 1280x720, got %v", q)
+	}
+	// arrasto para cima/esquerda
+	q = constrainCorner(image.Pt(500, 500), image.Pt(480, 490), selectionConstraint{RatioW: 1, RatioH: 1}, bounds)
+	if q != image.Pt(480, 480) {
+		t.Fatalf("quadrado invertido: %v", q)
+	}
+	// limitado à imagem mantendo a proporção
+	q = constrainCorner(image.Pt(1900, 0), image.Pt(2500, 300), selectionConstraint{RatioW: 4, RatioH: 3}, bounds)
+	if q != image.Pt(2000, 75) {
+		t.Fatalf("limite: %v", q)
+	}
+	// livre não altera o cursor
+	if q := constrainCorner(image.Pt(1, 1), image.Pt(7, 3), selectionConstraint{}, bounds); q != image.Pt(7, 3) {
+		t.Fatalf("livre: %v", q)
+	}
+}
+
+func TestConstrainCornerFixed(t *testing.T) {
+	bounds := image.Rect(0, 0, 1000, 800)
+	fixed := selectionConstraint{FixedW: 300, FixedH: 200}
+	if q := constrainCorner(image.Pt(10, 10), image.Pt(10, 10), fixed, bounds); q != image.Pt(310, 210) {
+		t.Fatalf("clique simples: %v", q)
+	}
+	// perto da borda direita/inferior vira para o outro lado
+	if q := constrainCorner(image.Pt(900, 700), image.Pt(950, 750), fixed, bounds); q != image.Pt(600, 500) {
+		t.Fatalf("virada: %v", q)
+	}
+}
+
+func TestConstrainHandle(t *testing.T) {
+	bounds := image.Rect(0, 0, 1000, 1000)
+	orig := image.Rect(100, 100, 260, 190) // 160x90
+	wide := selectionConstraint{RatioW: 16, RatioH: 9}
+
+	r := constrainHandle(4, orig, 160, 0, wide, bounds)
+	if r.Min != orig.Min || r.Dx() != 320 || r.Dy() != 180 {
+		t.Fatalf("canto: %v", r)
+	}
+	r = constrainHandle(8, orig, 160, 0, wide, bounds)
+	if r.Min != orig.Min || r.Dx() != 320 || r.Dy() != 180 {
+		t.Fatalf("borda direita: %v", r)
+	}
+	r = constrainHandle(1, orig, -80, 0, wide, bounds)
+	if r.Max != orig.Max || r.Dx() != 240 || r.Dy() != 135 {
+		t.Fatalf("canto superior esquerdo: %v", r)
+	}
+	// tamanho fixo: alças só movem
+	r = constrainHandle(4, orig, 30, 40, selectionConstraint{FixedW: 160, FixedH: 90}, bounds)
+	rectEq(t, r, orig.Add(image.Pt(30, 40)))
+}
+
+func TestCycleSizeAppliesToSelection(t *testing.T) {
+	app := &App{rawBG: blankCanvas(2000, 1200), sizePresets: loadSizePresets([]string{"1280x720"})}
+	app.setSelection(image.Rect(10, 20, 110, 120))
+	app.cycleSize()
+	rectEq(t, app.selectionRect(), image.Rect(10, 20, 1290, 740))
+	app.undo()
+	rectEq(t, app.selectionRect(), image.Rect(10, 20, 110, 120))
+	app.cycleSize() // desliga
+	if app.constraint().fixed() {
+		t.Fatalf("tamanho fixo deveria estar desligado")
+	}
+}
+
+func TestCycleSizeRefusesOversizedPreset(t *testing.T) {
+	app := &App{rawBG: blankCanvas(1600, 900), sizePresets: loadSizePresets([]string{"1920x1080", "1280x720"})}
+	app.setSelection(image.Rect(500, 300, 600, 400))
+	app.cycleSize()
+	if c := app.constraint(); c.FixedW != 1280 || c.FixedH != 720 {
+		t.Fatalf("deveria pular 1920×1080 e ficar em 1280×720: %v", c)
+	}
+	if !strings.Contains(app.infoMessage, "1920×1080 não cabe") {
+		t.Fatalf("recusa deveria ser avisada: %q", app.infoMessage)
+	}
+	// tamanho exato, empurrado para dentro da imagem em vez de encolher
+	rectEq(t, app.selectionRect(), image.Rect(320, 180, 1600, 900))
+
+	app.cycleSize()
+	if app.constraint().fixed() {
+		t.Fatalf("o ciclo deveria voltar a desligado")
+	}
+
+	// só presets grandes demais: desliga com o aviso
+	app = &App{rawBG: blankCanvas(800, 600), sizePresets: loadSizePresets([]string{"1920x1080"})}
+	app.cycleSize()
+	if app.constraint().fixed() || !strings.Contains(app.infoMessage, "não cabe") {
+		t.Fatalf("preset maior que a captura deveria ser recusado: %v %q", app.constraint(), app.infoMessage)
+	}
+}
+
+func TestSmallerCaptureDropsFixedSize(t *testing.T) {
+	app := &App{rawBG: blankCanvas(2000, 1200), sizePresets: loadSizePresets([]string{"1920x1080"})}
+	app.cycleSize()
+	app.receiveCapture(blankCanvas(1280, 1024))
+	if app.constraint().fixed() || !strings.Contains(app.infoMessage, "desligado") {
+		t.Fatalf("tamanho fixo deveria ser desligado na captura menor: %q", app.infoMessage)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Tarjas de redação: diferente das formas vetoriais, alteram os próprios
+// pixels da exportação. Em todos os modos o resultado depende apenas de
+// médias por bloco (ou de nada, na tarja preta), então o conteúdo original
+// não pode ser reconstruído a partir do arquivo salvo.
+const (
+	pixelateBlock = 10 // lado do bloco em pixels
+	blurBlock     = 6  // quantização aplicada antes do desfoque
+	blurSigma     = 4.0
+)
+
+func (t annotationTool) isRedaction() bool {
+	return t == toolPixelate || t == toolBlur || t == toolBlackout
+}
+
+// region é o retângulo coberto por uma tarja (inclui o pixel final).
+func (an annotation) region() image.Rectangle {
+	r := pointsBounds(an.Points)
+	r.Max = r.Max.Add(image.Pt(1, 1))
+	return r
+}
+
+// redact aplica a tarja em r (coordenadas de dst).
+func redact(dst *image.RGBA, tool annotationTool, r image.Rectangle) {
+	r = r.Intersect(dst.Bounds())
+	if r.Empty() {
+		return
+	}
+	switch tool {
+	case toolPixelate:
+		pixelate(dst, r, pixelateBlock)
+	case toolBlur:
+		// o desfoque sozinho pode ser revertido (deconvolução); quantizar em
+		// blocos antes descarta a informação de fato
+		pixelate(dst, r, blurBlock)
+		gaussianBlur(dst, r, blurSigma)
+	case toolBlackout:
+		black := color.RGBA{A: 255}
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				dst.SetRGBA(x, y, black)
+			}
+		}
+	}
+}
+
+// blockEdges divide [lo, hi) em faixas de block pixels a partir de lo e
+// devolve as fronteiras (lo e hi inclusive). Uma sobra menor que block/2 é
+// somada à faixa anterior: uma faixa estreita (no limite, 1px) ficaria com
+// praticamente os pixels originais.
+func blockEdges(lo, hi, block int) []int {
+	edges := []int{lo}
+	for e := lo + block; e < hi; e += block {
+		edges = append(edges, e)
+	}
+	if rem := (hi - lo) % block; rem > 0 && 2*rem < block && len(edges) > 1 {
+		edges = edges[:len(edges)-1]
+	}
+	return append(edges, hi)
+}
+
+// pixelate substitui cada bloco (alinhado ao canto de r, ver blockEdges)
+// pela sua média. O resultado é opaco: o alfa original também é descartado.
+func pixelate(img *image.RGBA, r image.Rectangle, block int) {
+	xs, ys := blockEdges(r.Min.X, r.Max.X, block), blockEdges(r.Min.Y, r.Max.Y, block)
+	for i := 0; i+1 < len(ys); i++ {
+		for j := 0; j+1 < len(xs); j++ {
+			b := image.Rect(xs[j], ys[i], xs[j+1], ys[i+1])
+			var sr, sg, sb, n int
+			for y := b.Min.Y; y < b.Max.Y; y++ {
+				for x := b.Min.X; x < b.Max.X; x++ {
+					c := img.RGBAAt(x, y)
+					sr += int(c.R)
+					sg += int(c.G)
+					sb += int(c.B)
+					n++
+				}
+			}
+			avg := color.RGBA{R: uint8(sr / n), G: uint8(sg / n), B: uint8(sb / n), A: 255}
+			for y := b.Min.Y; y < b.Max.Y; y++ {
+				for x := b.Min.X; x < b.Max.X; x++ {
+					img.SetRGBA(x, y, avg)
+				}
+			}
+		}
+	}
+}
+
+// gaussianBlur desfoca r de forma separável, lendo apenas pixels de dentro
+// de r (bordas replicadas).
+func gaussianBlur(img *image.RGBA, r image.Rectangle, sigma float64) {
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, 2*radius+1)
+	var sum float64
+	for i := range kernel {
+		d := float64(i - radius)
+		kernel[i] = math.Exp(-d * d / (2 * sigma * sigma))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+
+	w, h := r.Dx(), r.Dy()
+	buf := make([][3]float64, w*h)
+	tmp := make([][3]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := img.RGBAAt(r.Min.X+x, r.Min.Y+y)
+			buf[y*w+x] = [3]float64{float64(c.R), float64(c.G), float64(c.B)}
+		}
+	}
+	pass := func(src, dst [][3]float64, horizontal bool) {
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				var acc [3]float64
+				for k, kv := range kernel {
+					sx, sy := x, y
+					if horizontal {
+						sx = max(0, min(w-1, x+k-radius))
+					} else {
+						sy = max(0, min(h-1, y+k-radius))
+					}
+					p := src[sy*w+sx]
+					acc[0] += p[0] * kv
+					acc[1] += p[1] * kv
+					acc[2] += p[2] * kv
+				}
+				dst[y*w+x] = acc
+			}
+		}
+	}
+	pass(buf, tmp, true)
+	pass(tmp, buf, false)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := buf[y*w+x]
+			img.SetRGBA(r.Min.X+x, r.Min.Y+y, color.RGBA{
+				R: uint8(math.Round(p[0])),
+				G: uint8(math.Round(p[1])),
+				B: uint8(math.Round(p[2])),
+				A: 255,
+			})
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// noiseImage gera ruído; twinNoise gera outra imagem com as mesmas médias por
+// bloco de lado block, mas pixels diferentes.
+func noiseImage(w, h int, seed int64) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	rng := rand.New(rand.NewSource(seed))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(rng.Intn(256)), G: uint8(rng.Intn(256)), B: uint8(rng.Intn(256)), A: 255})
+		}
+	}
+	return img
+}
+
+// blocks lista os blocos em que pixelate divide r.
+func blocks(r image.Rectangle, block int) []image.Rectangle {
+	xs, ys := blockEdges(r.Min.X, r.Max.X, block), blockEdges(r.Min.Y, r.Max.Y, block)
+	var out []image.Rectangle
+	for i := 0; i+1 < len(ys); i++ {
+		for j := 0; j+1 < len(xs); j++ {
+			out = append(out, image.Rect(xs[j], ys[i], xs[j+1], ys[i+1]))
+		}
+	}
+	return out
+}
+
+func twinNoise(src *image.RGBA, r image.Rectangle, block int) *image.RGBA {
+	img := image.NewRGBA(src.Bounds())
+	copy(img.Pix, src.Pix)
+	for _, b := range blocks(r, block) {
+		// espelha o bloco horizontalmente: mesma média, outros pixels
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				img.SetRGBA(x, y, src.RGBAAt(b.Max.X-1-(x-b.Min.X), y))
+			}
+		}
+	}
+	return img
+}
+
+func TestPixelateUsesBlockAverages(t *testing.T) {
+	img := noiseImage(40, 30, 1)
+	r := image.Rect(5, 5, 33, 27)
+	redact(img, toolPixelate, r)
+	for _, b := range blocks(r, pixelateBlock) {
+		want := img.RGBAAt(b.Min.X, b.Min.Y)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				if img.RGBAAt(x, y) != want {
+					t.Fatalf("bloco %v não é uniforme em (%d,%d)", b, x, y)
+				}
+			}
+		}
+	}
+	// fora da região nada muda
+	ref := noiseImage(40, 30, 1)
+	if img.RGBAAt(2, 2) != ref.RGBAAt(2, 2) || img.RGBAAt(35, 28) != ref.RGBAAt(35, 28) {
+		t.Fatalf("pixels fora da tarja foram alterados")
+	}
+}
+
+func TestBlockEdges(t *testing.T) {
+	for _, tc := range []struct {
+		lo, hi, block int
+		want          []int
+	}{
+		{0, 20, 10, []int{0, 10, 20}},
+		{0, 21, 10, []int{0, 10, 21}}, // sobra de 1px vai para o bloco anterior
+		{0, 24, 10, []int{0, 10, 24}},
+		{0, 25, 10, []int{0, 10, 20, 25}}, // metade do bloco já fica sozinha
+		{5, 12, 10, []int{5, 12}},         // região menor que um bloco
+	} {
+		if got := blockEdges(tc.lo, tc.hi, tc.block); !slices.Equal(got, tc.want) {
+			t.Errorf("blockEdges(%d, %d, %d) = %v; want %v", tc.lo, tc.hi, tc.block, got, tc.want)
+		}
+	}
+}
+
+// Com lado ≡ 1 (mod bloco), a última linha/coluna não pode virar um bloco de
+// 1px que mantém o pixel original.
+func TestPixelateLeavesNoSourcePixel(t *testing.T) {
+	for _, size := range []int{21, 31} {
+		src := noiseImage(size, size, 5)
+		img := image.NewRGBA(src.Bounds())
+		copy(img.Pix, src.Pix)
+		redact(img, toolPixelate, src.Bounds())
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				if img.RGBAAt(x, y) == src.RGBAAt(x, y) {
+					t.Fatalf("%d×%d: pixel (%d,%d) manteve a cor original", size, size, x, y)
+				}
+			}
+		}
+	}
+}
+
+// Duas imagens diferentes com as mesmas médias por bloco precisam gerar a
+// mesma exportação: o arquivo não carrega nada além dessas médias.
+func TestRedactionIsNotRecoverable(t *testing.T) {
+	r := image.Rect(0, 0, 60, 60)
+	for _, c := range []struct {
+		tool  annotationTool
+		block int
+	}{
+		{toolPixelate, pixelateBlock},
+		{toolBlur, blurBlock},
+		{toolBlackout, pixelateBlock},
+	} {
+		a := noiseImage(60, 60, 7)
+		b := twinNoise(a, r, c.block)
+		if bytes.Equal(a.Pix, b.Pix) {
+			t.Fatalf("%v: imagens de teste idênticas", c.tool)
+		}
+		redact(a, c.tool, r)
+		redact(b, c.tool, r)
+		if !bytes.Equal(a.Pix, b.Pix) {
+			t.Fatalf("%v: resultado depende dos pixels originais", c.tool)
+		}
+	}
+}
+
+func TestBlackoutIsOpaque(t *testing.T) {
+	img := noiseImage(20, 20, 3)
+	redact(img, toolBlackout, image.Rect(2, 2, 18, 18))
+	for y := 2; y < 18; y++ {
+		for x := 2; x < 18; x++ {
+			if c := img.RGBAAt(x, y); c != (color.RGBA{A: 255}) {
+				t.Fatalf("pixel (%d,%d) = %v", x, y, c)
+			}
+		}
+	}
+}
+
+func TestRedactionAppliedOnExportOnly(t *testing.T) {
+	raw := noiseImage(80, 60, 5)
+	orig := image.NewRGBA(raw.Bounds())
+	copy(orig.Pix, raw.Pix)
+	clip := &fakeClipboard{}
+	app := &App{rawBG: raw, clipboard: clip}
+	app.selX0, app.selY0, app.selX1, app.selY1 = 10, 10, 70, 50
+	app.hasSelection = true
+	app.addAnnotation(annotation{Tool: toolBlackout, Points: []image.Point{{20, 20}, {39, 29}}})
+
+	app.doCopy()
+	got, err := png.Decode(bytes.NewReader(clip.data))
+	if err != nil {
+		t.Fatalf("png: %v", err)
+	}
+	// região (20,20)-(40,30) em rawBG vira (10,10)-(30,20) no recorte
+	for y := 10; y < 20; y++ {
+		for x := 10; x < 30; x++ {
+			if c := color.RGBAModel.Convert(got.At(x, y)); c != (color.RGBA{A: 255}) {
+				t.Fatalf("pixel (%d,%d) vazou: %v", x, y, c)
+			}
+		}
+	}
+	if !bytes.Equal(raw.Pix, orig.Pix) {
+		t.Fatalf("rawBG não deve ser alterado pela tarja")
+	}
+}
+
+func TestRedactionRequiresArea(t *testing.T) {
+	thin := annotation{Tool: toolPixelate, Points: []image.Point{{5, 5}, {40, 5}}}
+	if thin.valid() {
+		t.Fatalf("tarja sem altura não deveria ser aceita")
+	}
+	ok := annotation{Tool: toolBlur, Points: []image.Point{{5, 5}, {40, 9}}}
+	if !ok.valid() || ok.region() != image.Rect(5, 5, 41, 10) {
+		t.Fatalf("região inesperada: %v", ok.region())
+	}
+}
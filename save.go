@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// writeFileUnique grava data em path sem nunca sobrescrever um arquivo
+// existente: se o nome já estiver ocupado, tenta nome-1.ext, nome-2.ext, ...
+// A escrita é atômica (temporário + fsync + rename). Retorna o caminho final.
+func writeFileUnique(path string, data []byte) (string, error) {
+	dir := filepath.Dir(path)
+	tmp, err := writeTemp(dir, data)
+	if err != nil {
+		return "", err
+	}
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 0; ; i++ {
+		candidate := path
+		if i > 0 {
+			candidate = fmt.Sprintf("%s-%d%s", base, i, ext)
+		}
+		// reserva o nome de forma exclusiva; o rename substitui a reserva
+		f, err := os.OpenFile(candidate, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			_ = os.Remove(tmp)
+			return "", err
+		}
+		_ = f.Close()
+		if err := os.Rename(tmp, candidate); err != nil {
+			_ = os.Remove(candidate)
+			_ = os.Remove(tmp)
+			return "", err
+		}
+		syncDir(dir)
+		return candidate, nil
+	}
+}
+
+// writeFileAtomic grava data em path (sobrescrevendo) de forma atômica.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := writeTemp(dir, data)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	syncDir(dir)
+	return nil
+}
+
+// writeTemp cria um temporário no mesmo diretório do destino (para o rename
+// ser atômico), grava os dados e faz fsync antes de fechar.
+func writeTemp(dir string, data []byte) (string, error) {
+	f, err := os.CreateTemp(dir, ".snip-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	name := f.Name()
+	fail := func(err error) (string, error) {
+		_ = f.Close()
+		_ = os.Remove(name)
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		return fail(err)
+	}
+	if err := f.Sync(); err != nil {
+		return fail(err)
+	}
+	if err := f.Chmod(0o644); err != nil && runtime.GOOS != "windows" {
+		return fail(err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(name)
+		return "", err
+	}
+	return name, nil
+}
+
+// syncDir persiste a entrada do diretório após o rename (melhor esforço; não
+// suportado no Windows).
+func syncDir(dir string) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
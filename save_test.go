@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ler diretório: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestWriteFileUniqueNeverOverwrites(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "snip-20250101-120000.png")
+
+	var got []string
+	for i, data := range []string{"primeiro", "segundo", "terceiro"} {
+		p, err := writeFileUnique(path, []byte(data))
+		if err != nil {
+			t.Fatalf("escrita %d: %v", i, err)
+		}
+		got = append(got, filepath.Base(p))
+	}
+	want := []string{"snip-20250101-120000.png", "snip-20250101-120000-1.png", "snip-20250101-120000-2.png"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("nomes = %v; want %v", got, want)
+		}
+	}
+	if data, _ := os.ReadFile(path); string(data) != "primeiro" {
+		t.Fatalf("primeira captura foi sobrescrita: %q", data)
+	}
+	for _, name := range listDir(t, dir) {
+		if strings.HasSuffix(name, ".tmp") {
+			t.Fatalf("temporário esquecido no diretório: %s", name)
+		}
+	}
+}
+
+func TestWriteFileAtomicReplaces(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.png")
+	if err := writeFileAtomic(path, []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(path, []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "v2" {
+		t.Fatalf("conteúdo = %q; want v2", data)
+	}
+	if names := listDir(t, dir); len(names) != 1 {
+		t.Fatalf("esperava só o arquivo final, veio %v", names)
+	}
+}
+
+func TestDoSaveTwiceInSameSecondKeepsBoth(t *testing.T) {
+	dir := t.TempDir()
+	raw := sampleImage(20, 20, false)
+	app := &App{rawBG: raw, cfg: Config{Dir: dir, NameTemplate: "fixo"}}
+
+	var saved []string
+	for i := 0; i < 2; i++ {
+		app.selX0, app.selY0, app.selX1, app.selY1 = 0, 0, 10+i, 10
+		app.hasSelection = true
+		app.doSave()
+		if app.savedPath == "" {
+			t.Fatalf("save %d falhou: %q", i, app.infoMessage)
+		}
+		saved = append(saved, app.savedPath)
+	}
+	if saved[0] == saved[1] {
+		t.Fatalf("dois saves no mesmo nome: %v", saved)
+	}
+	if names := listDir(t, dir); len(names) != 2 {
+		t.Fatalf("esperava 2 arquivos, veio %v", names)
+	}
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// applyHandle desloca o retângulo como se a alça handle (ver
+// selectionHandle) fosse arrastada por (dx, dy). É o mesmo modelo usado no
+// ajuste com o mouse e na edição pelo teclado.
+func applyHandle(handle int, x0, y0, x1, y1, dx, dy int) (int, int, int, int) {
+	switch handle {
+	case 1:
+		x0 += dx
+		y0 += dy
+	case 2:
+		x0 += dx
+		y1 += dy
+	case 3:
+		x1 += dx
+		y0 += dy
+	case 4:
+		x1 += dx
+		y1 += dy
+	case 5:
+		y0 += dy
+	case 6:
+		y1 += dy
+	case 7:
+		x0 += dx
+	case 8:
+		x1 += dx
+	case 9:
+		x0 += dx
+		x1 += dx
+		y0 += dy
+		y1 += dy
+	}
+	return normRect(x0, y0, x1, y1)
+}
+
+// nudgeSelection aplica um passo de teclado na seleção travada, mantendo-a
+// dentro da imagem. Mover (alça 9) preserva o tamanho; redimensionar não
+// deixa a seleção menor que 2px.
+func nudgeSelection(sel, bounds image.Rectangle, handle, dx, dy int) image.Rectangle {
+	if handle == 9 {
+		r := sel.Add(image.Pt(dx, dy))
+		r = r.Add(image.Pt(max(0, bounds.Min.X-r.Min.X), max(0, bounds.Min.Y-r.Min.Y)))
+		r = r.Sub(image.Pt(max(0, r.Max.X-bounds.Max.X), max(0, r.Max.Y-bounds.Max.Y)))
+		return r
+	}
+	x0, y0, x1, y1 := applyHandle(handle, sel.Min.X, sel.Min.Y, sel.Max.X, sel.Max.Y, dx, dy)
+	r := image.Rect(x0, y0, x1, y1).Intersect(bounds)
+	if r.Dx() < 2 || r.Dy() < 2 {
+		return sel
+	}
+	return r
+}
+
+// keyHandle escolhe a alça que as setas deslocam: sem Alt a seleção inteira,
+// Alt+setas as bordas direita/inferior e Shift+Alt+setas as bordas
+// esquerda/superior. (Ctrl+Alt+setas troca de área de trabalho no GNOME,
+// KDE e XFCE e nunca chega ao app.)
+func keyHandle(alt, shift, horizontal bool) int {
+	switch {
+	case !alt:
+		return 9
+	case shift && horizontal:
+		return 7
+	case shift:
+		return 5
+	case horizontal:
+		return 8
+	}
+	return 6
+}
+
+// nudgeState lembra o último ajuste pelo teclado, para juntar a repetição
+// de uma seta segurada em uma entrada só do histórico.
+type nudgeState struct {
+	key    ebiten.Key
+	handle int
+	cmd    selectionCmd // comando no topo do histórico
+}
+
+// recordNudge grava um ajuste pelo teclado no histórico. Se a mesma tecla
+// continua segurada (held), com a mesma alça, e o topo do histórico ainda é
+// o ajuste anterior, ele é estendido: um desfazer volta o ajuste inteiro.
+func (a *App) recordNudge(held bool, key ebiten.Key, handle int, c selectionCmd) {
+	n := &a.nudge
+	if held && n.key == key && n.handle == handle {
+		if d := a.history.done; len(d) > 0 {
+			if last, ok := d[len(d)-1].(selectionCmd); ok && last == n.cmd {
+				c.from = last.from
+				d[len(d)-1] = c
+				n.cmd = c
+				return
+			}
+		}
+	}
+	a.record(c)
+	*n = nudgeState{key: key, handle: handle, cmd: c}
+}
+
+// updateSelectionKeys: setas movem a seleção (Shift = 10px); com Alt
+// redimensionam pelas bordas, 1px por vez (ver keyHandle).
+func (a *App) updateSelectionKeys() {
+	if !a.hasSelection || a.selecting || a.adjusting || a.drawing != nil {
+		return
+	}
+	alt := ebiten.IsKeyPressed(ebiten.KeyAlt)
+	shift := ebiten.IsKeyPressed(ebiten.KeyShift)
+	step := 1
+	if shift && !alt {
+		step = 10
+	}
+
+	for _, k := range []struct {
+		key    ebiten.Key
+		dx, dy int
+	}{
+		{ebiten.KeyArrowLeft, -step, 0},
+		{ebiten.KeyArrowRight, step, 0},
+		{ebiten.KeyArrowUp, 0, -step},
+		{ebiten.KeyArrowDown, 0, step},
+	} {
+		if !repeatingKey(k.key) {
+			continue
+		}
+		handle := keyHandle(alt, shift, k.dx != 0)
+		from := a.selectionRect()
+		to := nudgeSelection(from, a.rawBG.Bounds(), handle, k.dx, k.dy)
+		if to != from {
+			a.setSelection(to)
+			a.recordNudge(inpututil.KeyPressDuration(k.key) > 1, k.key, handle, selectionCmd{from: from, to: to})
+			a.infoMessage = "Seleção: " + selectionLabel(to, image.Point{}, false)
+		}
+	}
+}
+
+// ---- entrada exata x,y,w,h ----
+
+func (a *App) openRegionPrompt() {
+	a.prompting = true
+	a.promptText = ""
+	if a.hasSelection {
+		r := a.selectionRect()
+		a.promptText = fmt.Sprintf("%d,%d,%d,%d", r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+	}
+	a.infoMessage = "Digite x,y,w,h e Enter (Esc cancela)"
+}
+
+// updateRegionPrompt consome o teclado enquanto o prompt está aberto.
+func (a *App) updateRegionPrompt() {
+	for _, r := range ebiten.AppendInputChars(nil) {
+		if (r >= '0' && r <= '9') || r == ',' || r == ' ' || r == '-' {
+			a.promptText += string(r)
+		}
+	}
+	if repeatingKey(ebiten.KeyBackspace) && a.promptText != "" {
+		a.promptText = a.promptText[:len(a.promptText)-1]
+	}
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		a.prompting = false
+		a.infoMessage = "Entrada cancelada."
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		if err := a.applyRegionText(a.promptText); err != nil {
+			a.infoMessage = "Região inválida: " + err.Error()
+			return
+		}
+		a.prompting = false
+	}
+}
+
+// applyRegionText define a seleção a partir de "x,y,w,h" (pixels da imagem).
+func (a *App) applyRegionText(s string) error {
+	r, err := parseRegion(s)
+	if err != nil {
+		return err
+	}
+	if !r.In(a.rawBG.Bounds()) {
+		return fmt.Errorf("fora da imagem (%dx%d)", a.rawBG.Bounds().Dx(), a.rawBG.Bounds().Dy())
+	}
+	if r.Dx() < 2 || r.Dy() < 2 {
+		return fmt.Errorf("mínimo de 2x2")
+	}
+	var from image.Rectangle
+	if a.hasSelection {
+		from = a.selectionRect()
+	}
+	a.finishTextEdit()
+	a.setSelection(r)
+	a.record(selectionCmd{from: from, to: r})
+	a.infoMessage = "Seleção pronta. Use Salvar/Enter ou Cancelar/Esc."
+	return nil
+}
+
+// ---- leitura de tamanho/posição ----
+
+// selectionLabel descreve a seleção em pixels da imagem. No modo "todos
+// monitores" acrescenta a origem em coordenadas do desktop virtual (desktop
+// é o ponto do desktop que corresponde ao pixel 0,0 da imagem).
+func selectionLabel(r image.Rectangle, desktop image.Point, modeAll bool) string {
+	s := fmt.Sprintf("%d×%d @ %d,%d", r.Dx(), r.Dy(), r.Min.X, r.Min.Y)
+	if modeAll {
+		d := r.Min.Add(desktop)
+		s += fmt.Sprintf(" (desktop %d,%d)", d.X, d.Y)
+	}
+	return s
+}
+
+// drawSelectionReadout mostra W×H e origem junto ao canto da seleção,
+// acima dela quando há espaço, senão logo por dentro.
+func (a *App) drawSelectionReadout(screen *ebiten.Image, r image.Rectangle) {
+	if r.Empty() {
+		return
+	}
+	scale := max(a.viewScale, 1)
+	var desktop image.Point
+	if a.modeAll {
+		desktop = virtualBounds(a.displays).Min
+	}
+	x := float64(r.Min.X)
+	y := float64(r.Min.Y) - 22*scale
+	if y < 0 {
+		y = float64(r.Min.Y) + 4*scale
+		x += 4 * scale
+	}
+	a.drawScaledLabel(screen, selectionLabel(r, desktop, a.modeAll), x, y, scale)
+}
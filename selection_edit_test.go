@@ -0,0 +1,137 @@
+package main
+
+import (
+	"image"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestApplyHandle(t *testing.T) {
+	cases := []struct {
+		handle, dx, dy int
+		want           image.Rectangle
+	}{
+		{1, 5, 5, image.Rect(15, 15, 50, 40)},
+		{4, 10, -5, image.Rect(10, 10, 60, 35)},
+		{5, 0, 3, image.Rect(10, 13, 50, 40)},
+		{8, -50, 0, image.Rect(0, 10, 10, 40)}, // passou da borda oposta: normRect inverte
+		{9, -3, 2, image.Rect(7, 12, 47, 42)},
+		{0, 9, 9, image.Rect(10, 10, 50, 40)},
+	}
+	for _, c := range cases {
+		x0, y0, x1, y1 := applyHandle(c.handle, 10, 10, 50, 40, c.dx, c.dy)
+		rectEq(t, image.Rect(x0, y0, x1, y1), c.want)
+	}
+}
+
+func TestNudgeSelection(t *testing.T) {
+	bounds := image.Rect(0, 0, 100, 80)
+	sel := image.Rect(10, 10, 40, 30)
+
+	rectEq(t, nudgeSelection(sel, bounds, 9, 1, 0), image.Rect(11, 10, 41, 30))
+	rectEq(t, nudgeSelection(sel, bounds, 9, 0, -10), image.Rect(10, 0, 40, 20))
+	// mover não sai da imagem nem muda o tamanho
+	rectEq(t, nudgeSelection(sel, bounds, 9, -50, 100), image.Rect(0, 60, 30, 80))
+	// Alt: borda direita/inferior
+	rectEq(t, nudgeSelection(sel, bounds, 8, 10, 0), image.Rect(10, 10, 50, 30))
+	rectEq(t, nudgeSelection(sel, bounds, 6, 0, -1), image.Rect(10, 10, 40, 29))
+	// redimensionar é limitado à imagem
+	rectEq(t, nudgeSelection(sel, bounds, 8, 500, 0), image.Rect(10, 10, 100, 30))
+	// não encolhe abaixo de 2px
+	tiny := image.Rect(10, 10, 12, 12)
+	rectEq(t, nudgeSelection(tiny, bounds, 8, -1, 0), tiny)
+}
+
+func TestKeyHandle(t *testing.T) {
+	cases := []struct {
+		alt, shift, horizontal bool
+		want                   int
+	}{
+		{false, false, true, 9},
+		{false, true, false, 9}, // Shift sem Alt só aumenta o passo
+		{true, false, true, 8},
+		{true, false, false, 6},
+		{true, true, true, 7},
+		{true, true, false, 5},
+	}
+	for _, c := range cases {
+		if got := keyHandle(c.alt, c.shift, c.horizontal); got != c.want {
+			t.Fatalf("keyHandle(alt=%v, shift=%v, horizontal=%v) = %d; want %d", c.alt, c.shift, c.horizontal, got, c.want)
+		}
+	}
+}
+
+func TestHeldNudgeIsOneUndo(t *testing.T) {
+	app := &App{rawBG: blankCanvas(100, 100)}
+	start := image.Rect(10, 10, 30, 30)
+	app.setSelection(start)
+
+	nudge := func(held bool, key ebiten.Key, handle, dx int) {
+		from := app.selectionRect()
+		to := nudgeSelection(from, app.rawBG.Bounds(), handle, dx, 0)
+		app.setSelection(to)
+		app.recordNudge(held, key, handle, selectionCmd{from: from, to: to})
+	}
+	nudge(false, ebiten.KeyArrowRight, 9, 1) // toque
+	for i := 0; i < 20; i++ {
+		nudge(true, ebiten.KeyArrowRight, 9, 1) // repetição da tecla segurada
+	}
+	if n := len(app.history.done); n != 1 {
+		t.Fatalf("tecla segurada deveria virar 1 entrada; %d", n)
+	}
+	nudge(false, ebiten.KeyArrowRight, 9, 1) // novo toque: outra entrada
+	nudge(true, ebiten.KeyArrowRight, 8, 1)  // Alt no meio: outra alça, outra entrada
+	if n := len(app.history.done); n != 3 {
+		t.Fatalf("toques e alças diferentes deveriam separar entradas; %d", n)
+	}
+
+	app.undo()
+	app.undo()
+	app.undo()
+	rectEq(t, app.selectionRect(), start)
+}
+
+func TestApplyRegionText(t *testing.T) {
+	app := &App{rawBG: blankCanvas(200, 100)}
+	if err := app.applyRegionText("10, 20, 30, 40"); err != nil {
+		t.Fatalf("applyRegionText: %v", err)
+	}
+	if !app.hasSelection {
+		t.Fatalf("seleção não criada")
+	}
+	rectEq(t, app.selectionRect(), image.Rect(10, 20, 40, 60))
+
+	for _, bad := range []string{"10,20,30", "190,0,20,20", "0,0,1,1", "a,b,c,d"} {
+		if err := app.applyRegionText(bad); err == nil {
+			t.Fatalf("%q deveria falhar", bad)
+		}
+	}
+	rectEq(t, app.selectionRect(), image.Rect(10, 20, 40, 60))
+
+	// entra no histórico
+	app.undo()
+	if app.hasSelection {
+		t.Fatalf("desfazer deveria remover a seleção digitada")
+	}
+}
+
+func TestOpenRegionPromptPrefills(t *testing.T) {
+	app := &App{rawBG: blankCanvas(200, 100)}
+	app.setSelection(image.Rect(5, 6, 25, 36))
+	app.openRegionPrompt()
+	if !app.prompting || app.promptText != "5,6,20,30" {
+		t.Fatalf("prompt: %v %q", app.prompting, app.promptText)
+	}
+}
+
+func TestSelectionLabel(t *testing.T) {
+	r := image.Rect(100, 50, 1380, 770)
+	if got, want := selectionLabel(r, image.Point{}, false), "1280×720 @ 100,50"; got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+	// modo todos: imagem começa no canto do desktop virtual (monitor à esquerda em -1920)
+	if got, want := selectionLabel(r, image.Pt(-1920, 0), true), "1280×720 @ 100,50 (desktop -1820,50)"; got != want {
+		t.Fatalf("got %q want %q", got, want)
+	}
+}
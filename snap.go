@@ -0,0 +1,246 @@
+package main
+
+import "image"
+
+const (
+	edgeThreshold = 48 // |ΔR|+|ΔG|+|ΔB| mínimo para contar como transição
+	snapTolerance = 8  // distância (pixels de tela) em que a borda gruda
+)
+
+// edgeMap marca as transições fortes de cor de uma imagem. vert guarda a
+// fronteira entre as colunas x-1 e x (índice x, de 0 a w) e horiz a
+// fronteira entre as linhas y-1 e y; as bordas da imagem contam como
+// fronteira. Os índices coincidem com Min/Max de um retângulo de seleção.
+type edgeMap struct {
+	origin      image.Point
+	w, h        int
+	vert, horiz []uint64 // bitsets (w+1)×h e w×(h+1)
+}
+
+func newEdgeMap(img *image.RGBA) *edgeMap {
+	b := img.Bounds()
+	m := &edgeMap{origin: b.Min, w: b.Dx(), h: b.Dy()}
+	m.vert = make([]uint64, ((m.w+1)*m.h+63)/64)
+	m.horiz = make([]uint64, (m.w*(m.h+1)+63)/64)
+	for y := 0; y < m.h; y++ {
+		for x := 0; x < m.w; x++ {
+			i := img.PixOffset(b.Min.X+x, b.Min.Y+y)
+			p := img.Pix[i : i+3 : i+3]
+			if x == 0 || colorStep(img.Pix[i-4:i-1], p) {
+				setBit(m.vert, y*(m.w+1)+x)
+			}
+			if y == 0 || colorStep(img.Pix[i-img.Stride:i-img.Stride+3], p) {
+				setBit(m.horiz, y*m.w+x)
+			}
+		}
+		setBit(m.vert, y*(m.w+1)+m.w)
+	}
+	for x := 0; x < m.w; x++ {
+		setBit(m.horiz, m.h*m.w+x)
+	}
+	return m
+}
+
+func colorStep(a, b []byte) bool {
+	d := abs(int(a[0])-int(b[0])) + abs(int(a[1])-int(b[1])) + abs(int(a[2])-int(b[2]))
+	return d >= edgeThreshold
+}
+
+func setBit(bits []uint64, i int) { bits[i/64] |= 1 << (i % 64) }
+func hasBit(bits []uint64, i int) bool {
+	return bits[i/64]&(1<<(i%64)) != 0
+}
+
+// coverage conta quantos pixels da fronteira vertical x (ou horizontal y,
+// com vertical=false) estão marcados no intervalo [lo, hi).
+func (m *edgeMap) coverage(vertical bool, pos, lo, hi int) int {
+	n := 0
+	if vertical {
+		pos -= m.origin.X
+		lo, hi = max(lo-m.origin.Y, 0), min(hi-m.origin.Y, m.h)
+		if pos < 0 || pos > m.w {
+			return 0
+		}
+		for y := lo; y < hi; y++ {
+			if hasBit(m.vert, y*(m.w+1)+pos) {
+				n++
+			}
+		}
+		return n
+	}
+	pos -= m.origin.Y
+	lo, hi = max(lo-m.origin.X, 0), min(hi-m.origin.X, m.w)
+	if pos < 0 || pos > m.h {
+		return 0
+	}
+	for x := lo; x < hi; x++ {
+		if hasBit(m.horiz, pos*m.w+x) {
+			n++
+		}
+	}
+	return n
+}
+
+// snap procura, a até tol de pos, a fronteira que cobre ao menos metade do
+// intervalo [lo, hi); entre as candidatas vence a de maior cobertura e, no
+// empate, a mais próxima. Sem candidata, devolve pos e false.
+func (m *edgeMap) snap(vertical bool, pos, lo, hi, tol int) (int, bool) {
+	need := max((hi-lo+1)/2, 4)
+	best, bestN := pos, 0
+	for d := 0; d <= tol; d++ {
+		for _, p := range []int{pos - d, pos + d} {
+			if n := m.coverage(vertical, p, lo, hi); n >= need && n > bestN {
+				best, bestN = p, n
+			}
+		}
+	}
+	return best, bestN > 0
+}
+
+// snapRect gruda nas fronteiras detectadas as bordas de r que a alça handle
+// (ver applyHandle) movimenta. Mover (9) desloca a seleção inteira pela
+// menor correção encontrada em cada eixo, preservando o tamanho.
+func (m *edgeMap) snapRect(r image.Rectangle, handle, tol int) image.Rectangle {
+	left, top, right, bottom := handleEdges(handle)
+	x0, y0, x1, y1 := r.Min.X, r.Min.Y, r.Max.X, r.Max.Y
+	if handle == 9 {
+		dx := nearestShift(m, true, x0, x1, y0, y1, tol)
+		dy := nearestShift(m, false, y0, y1, x0, x1, tol)
+		return r.Add(image.Pt(dx, dy))
+	}
+	if left {
+		x0, _ = m.snap(true, x0, y0, y1, tol)
+	}
+	if right {
+		x1, _ = m.snap(true, x1, y0, y1, tol)
+	}
+	if top {
+		y0, _ = m.snap(false, y0, x0, x1, tol)
+	}
+	if bottom {
+		y1, _ = m.snap(false, y1, x0, x1, tol)
+	}
+	if x1-x0 < 2 || y1-y0 < 2 {
+		return r
+	}
+	return image.Rect(x0, y0, x1, y1)
+}
+
+// nearestShift devolve o deslocamento que leva a borda a ou b (a que estiver
+// mais perto) até uma fronteira.
+func nearestShift(m *edgeMap, vertical bool, a, b, lo, hi, tol int) int {
+	shift, found := 0, false
+	for _, pos := range []int{a, b} {
+		if p, ok := m.snap(vertical, pos, lo, hi, tol); ok && (!found || abs(p-pos) < abs(shift)) {
+			shift, found = p-pos, true
+		}
+	}
+	return shift
+}
+
+// handleEdges diz quais bordas cada alça movimenta.
+func handleEdges(handle int) (left, top, right, bottom bool) {
+	switch handle {
+	case 1:
+		return true, true, false, false
+	case 2:
+		return true, false, false, true
+	case 3:
+		return false, true, true, false
+	case 4:
+		return false, false, true, true
+	case 5:
+		return false, true, false, false
+	case 6:
+		return false, false, false, true
+	case 7:
+		return true, false, false, false
+	case 8:
+		return false, false, true, false
+	}
+	return false, false, false, false
+}
+
+// cornerHandle é a alça equivalente ao canto móvel de um arrasto novo.
+func cornerHandle(start, cur image.Point) int {
+	switch {
+	case cur.X < start.X && cur.Y < start.Y:
+		return 1
+	case cur.X < start.X:
+		return 2
+	case cur.Y < start.Y:
+		return 3
+	}
+	return 4
+}
+
+// ---- UI ----
+
+// snapping: o ímã fica ativo enquanto Ctrl está pressionado e não há trava
+// de proporção (as duas regras brigariam pelo mesmo pixel).
+func (a *App) snapping() bool {
+	return isCtrlPressed() && !a.constraint().active()
+}
+
+// buildEdges calcula o mapa de bordas de rawBG fora do loop do jogo: com
+// três monitores 4K ele leva tempo demais para um frame.
+func (a *App) buildEdges() {
+	src := a.rawBG
+	ready := make(chan *edgeMap, 1)
+	a.edgeSrc, a.edgeCache, a.edgeReady = src, nil, ready
+	go func() { ready <- newEdgeMap(src) }()
+}
+
+// edges devolve o mapa de bordas de rawBG, ou nil enquanto ele ainda está
+// sendo calculado (o ímã só passa a agir quando fica pronto).
+func (a *App) edges() *edgeMap {
+	if a.edgeSrc != a.rawBG {
+		a.buildEdges()
+	}
+	if a.edgeCache == nil {
+		select {
+		case m := <-a.edgeReady:
+			a.edgeCache = m
+		default:
+		}
+	}
+	return a.edgeCache
+}
+
+func (a *App) snapTol() int {
+	return int(snapTolerance * max(a.viewScale, 1))
+}
+
+// snapSelecting gruda o canto móvel do arrasto de uma seleção nova.
+func (a *App) snapSelecting() {
+	start, cur := image.Pt(a.startX, a.startY), image.Pt(a.curX, a.curY)
+	r := image.Rectangle{Min: start, Max: cur}.Canon()
+	m := a.edges()
+	if m == nil || r.Dx() < 2 || r.Dy() < 2 {
+		return
+	}
+	s := m.snapRect(r, cornerHandle(start, cur), a.snapTol())
+	if cur.X < start.X {
+		a.curX = s.Min.X
+	} else {
+		a.curX = s.Max.X
+	}
+	if cur.Y < start.Y {
+		a.curY = s.Min.Y
+	} else {
+		a.curY = s.Max.Y
+	}
+}
+
+// snapAdjusting gruda as bordas movidas pela alça em ajuste.
+func (a *App) snapAdjusting() {
+	m := a.edges()
+	if m == nil {
+		return
+	}
+	r := m.snapRect(a.selectionRect(), a.adjustHandle, a.snapTol())
+	r = r.Intersect(a.rawBG.Bounds())
+	if r.Dx() >= 2 && r.Dy() >= 2 {
+		a.selX0, a.selY0, a.selX1, a.selY1 = r.Min.X, r.Min.Y, r.Max.X, r.Max.Y
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+	"time"
+)
+
+// panelImage simula um diálogo (painel claro com borda escura) sobre fundo
+// cinza: as fronteiras do painel ficam em x=50/150 e y=40/120.
+func panelImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{90, 90, 90, 255}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(50, 40, 150, 120), image.NewUniform(color.RGBA{20, 20, 20, 255}), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(52, 42, 148, 118), image.NewUniform(color.RGBA{240, 240, 240, 255}), image.Point{}, draw.Src)
+	return img
+}
+
+func TestEdgeMapCoverage(t *testing.T) {
+	m := newEdgeMap(panelImage())
+	if n := m.coverage(true, 50, 40, 120); n != 80 {
+		t.Fatalf("borda esquerda do painel: %d de 80", n)
+	}
+	if n := m.coverage(false, 120, 50, 150); n != 100 {
+		t.Fatalf("borda inferior do painel: %d de 100", n)
+	}
+	if n := m.coverage(true, 30, 0, 200); n != 0 {
+		t.Fatalf("fundo liso não tem borda: %d", n)
+	}
+	if n := m.coverage(true, 300, 0, 200); n != 200 {
+		t.Fatalf("borda da imagem deveria contar: %d", n)
+	}
+}
+
+func TestSnapRectCorner(t *testing.T) {
+	m := newEdgeMap(panelImage())
+
+	// arrasto quase no canto do painel: gruda exatamente na borda externa
+	got := m.snapRect(image.Rect(50, 40, 146, 124), 4, 8)
+	rectEq(t, got, image.Rect(50, 40, 150, 120))
+
+	// fora da tolerância não mexe
+	r := image.Rect(50, 40, 170, 140)
+	rectEq(t, m.snapRect(r, 4, 8), r)
+
+	// só as bordas da alça se movem
+	got = m.snapRect(image.Rect(47, 43, 153, 117), 8, 8)
+	rectEq(t, got, image.Rect(47, 43, 150, 117))
+}
+
+func TestSnapRectMoveKeepsSize(t *testing.T) {
+	m := newEdgeMap(panelImage())
+	got := m.snapRect(image.Rect(53, 37, 153, 117), 9, 8)
+	rectEq(t, got, image.Rect(50, 40, 150, 120))
+}
+
+func TestSnapIgnoresShortEdges(t *testing.T) {
+	// uma borda que cobre menos da metade da seleção não atrai
+	m := newEdgeMap(panelImage())
+	r := image.Rect(10, 0, 148, 200)
+	rectEq(t, m.snapRect(r, 8, 8), r)
+}
+
+// waitEdges espera o mapa de bordas calculado em segundo plano.
+func waitEdges(t *testing.T, app *App) *edgeMap {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if m := app.edges(); m != nil {
+			return m
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("mapa de bordas não ficou pronto")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSnapSelectingReverseDrag(t *testing.T) {
+	app := &App{rawBG: panelImage()}
+	m := waitEdges(t, app)
+	app.startX, app.startY = 150, 120
+	app.curX, app.curY = 55, 44 // arrasto para cima/esquerda
+	app.snapSelecting()
+	if app.curX != 50 || app.curY != 40 {
+		t.Fatalf("canto móvel: %d,%d", app.curX, app.curY)
+	}
+	if app.edges() != m {
+		t.Fatalf("mapa de bordas deveria ficar em cache")
+	}
+}
+
+func TestEdgesRebuiltForNewCapture(t *testing.T) {
+	app := &App{rawBG: panelImage()}
+	old := waitEdges(t, app)
+	app.rawBG = blankCanvas(200, 200)
+	if m := waitEdges(t, app); m == old || app.edgeSrc != app.rawBG {
+		t.Fatalf("captura nova deveria ganhar outro mapa de bordas")
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	defaultTextSize = 20
+	minTextSize     = 8
+	maxTextSize     = 96
+)
+
+var (
+	textFontOnce sync.Once
+	textFont     *opentype.Font
+	textFontErr  error
+
+	textFacesMu sync.Mutex
+	textFaces   = map[int]font.Face{}
+)
+
+// textFace devolve a fonte Go Bold embutida no tamanho pedido (em pixels).
+func textFace(size int) (font.Face, error) {
+	textFontOnce.Do(func() {
+		textFont, textFontErr = opentype.Parse(gobold.TTF)
+	})
+	if textFontErr != nil {
+		return nil, textFontErr
+	}
+	textFacesMu.Lock()
+	defer textFacesMu.Unlock()
+	if f, ok := textFaces[size]; ok {
+		return f, nil
+	}
+	f, err := opentype.NewFace(textFont, &opentype.FaceOptions{
+		Size:    float64(size),
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		return nil, err
+	}
+	textFaces[size] = f
+	return f, nil
+}
+
+// textLayout posiciona o texto ancorado em Points[0] (canto superior
+// esquerdo da caixa). Retorna a caixa (com a margem da pílula) e a linha de
+// base onde o texto começa, ambas em coordenadas de rawBG.
+func textLayout(an annotation) (box image.Rectangle, dot fixed.Point26_6, face font.Face) {
+	face, err := textFace(an.Size)
+	if err != nil || len(an.Points) == 0 {
+		return image.Rectangle{}, fixed.Point26_6{}, nil
+	}
+	m := face.Metrics()
+	pad := max(2, an.Size*3/10)
+	width := font.MeasureString(face, an.Text).Ceil()
+	height := (m.Ascent + m.Descent).Ceil()
+	p := an.Points[0]
+	box = image.Rect(p.X, p.Y, p.X+width+2*pad, p.Y+height+2*pad)
+	dot = fixed.P(p.X+pad, p.Y+pad).Add(fixed.Point26_6{Y: m.Ascent})
+	return box, dot, face
+}
+
+// drawText desenha a anotação de texto (e a pílula de fundo) em dst.
+func drawText(dst *image.RGBA, an annotation, origin image.Point) {
+	box, dot, face := textLayout(an)
+	if face == nil {
+		return
+	}
+	box = box.Sub(origin)
+	if an.Pill {
+		r := float64(box.Dy()) / 2
+		mask := image.NewAlpha(box.Intersect(dst.Bounds()))
+		if !mask.Rect.Empty() {
+			fillPaths(mask, [][]vec2{roundedRectPath(box, r)})
+			draw.DrawMask(dst, mask.Rect, image.NewUniform(pillColor(an.Color)), image.Point{}, mask, mask.Rect.Min, draw.Over)
+		}
+	}
+	d := font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(an.Color),
+		Face: face,
+		Dot:  dot.Sub(fixed.P(origin.X, origin.Y)),
+	}
+	d.DrawString(an.Text)
+}
+
+// pillColor escolhe um fundo que contraste com a cor do texto.
+func pillColor(text color.RGBA) color.RGBA {
+	lum := 0.299*float64(text.R) + 0.587*float64(text.G) + 0.114*float64(text.B)
+	if lum > 140 {
+		return color.RGBA{A: 180} // preto translúcido (pré-multiplicado)
+	}
+	return color.RGBA{R: 230, G: 230, B: 230, A: 230}
+}
+
+func roundedRectPath(r image.Rectangle, radius float64) []vec2 {
+	x0, y0, x1, y1 := float64(r.Min.X), float64(r.Min.Y), float64(r.Max.X), float64(r.Max.Y)
+	radius = math.Min(radius, math.Min(x1-x0, y1-y0)/2)
+	corners := []struct {
+		c     vec2
+		start float64
+	}{
+		{vec2{x1 - radius, y0 + radius}, -math.Pi / 2},
+		{vec2{x1 - radius, y1 - radius}, 0},
+		{vec2{x0 + radius, y1 - radius}, math.Pi / 2},
+		{vec2{x0 + radius, y0 + radius}, math.Pi},
+	}
+	const steps = 8
+	var pts []vec2
+	for _, k := range corners {
+		for i := 0; i <= steps; i++ {
+			t := k.start + math.Pi/2*float64(i)/steps
+			pts = append(pts, vec2{k.c.X + radius*math.Cos(t), k.c.Y + radius*math.Sin(t)})
+		}
+	}
+	return pts
+}
+
+// ---- edição de texto (UI) ----
+
+// textAt devolve o índice da anotação de texto sob p (a mais recente), ou -1.
+func (a *App) textAt(p image.Point) int {
+	for i := len(a.annotations) - 1; i >= 0; i-- {
+		an := a.annotations[i]
+		if an.Tool != toolText {
+			continue
+		}
+		if box, _, _ := textLayout(an); p.In(box) {
+			return i
+		}
+	}
+	return -1
+}
+
+// placeText abre uma caixa nova em p, ou reabre a caixa existente sob p.
+func (a *App) placeText(p image.Point) {
+	a.finishTextEdit()
+	if i := a.textAt(p); i >= 0 {
+		orig := a.annotations[i]
+		a.editIndex = i
+		a.editOrig = &orig
+		a.editing = true
+		a.infoMessage = "Editando texto. Enter ou Esc termina."
+		return
+	}
+	a.annotations = append(a.annotations, annotation{
+		Tool:   toolText,
+		Points: []image.Point{p},
+		Color:  a.annotationColor(),
+		Size:   a.textSize,
+		Pill:   a.textPill,
+	})
+	a.editIndex = len(a.annotations) - 1
+	a.editOrig = nil
+	a.editing = true
+	a.annoDirty = true
+	a.infoMessage = "Digite o texto. Enter ou Esc termina."
+}
+
+// updateTextInput consome o teclado enquanto uma caixa está em edição; os
+// atalhos do app ficam suspensos até Enter/Esc.
+func (a *App) updateTextInput() {
+	an := &a.annotations[a.editIndex]
+	if chars := ebiten.AppendInputChars(nil); len(chars) > 0 {
+		an.Text += string(chars)
+		a.annoDirty = true
+	}
+	if repeatingKey(ebiten.KeyBackspace) && an.Text != "" {
+		r := []rune(an.Text)
+		an.Text = string(r[:len(r)-1])
+		a.annoDirty = true
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		a.finishTextEdit()
+	}
+}
+
+// finishTextEdit fecha a caixa em edição; caixas vazias são descartadas. A
+// edição inteira entra no histórico como um único passo.
+func (a *App) finishTextEdit() {
+	if !a.editing {
+		return
+	}
+	a.editing = false
+	i, orig := a.editIndex, a.editOrig
+	cur := a.annotations[i]
+	switch {
+	case cur.Text == "":
+		a.annotations = append(a.annotations[:i], a.annotations[i+1:]...)
+		if orig != nil {
+			a.record(annotationCmd{index: i, before: orig})
+		}
+		a.infoMessage = "Texto vazio descartado."
+	case orig == nil:
+		a.record(annotationCmd{index: i, after: &cur})
+		a.infoMessage = "Texto pronto. Clique nele para editar | Enter salva"
+	default:
+		if orig.Text != cur.Text {
+			a.record(annotationCmd{index: i, before: orig, after: &cur})
+		}
+		a.infoMessage = "Texto pronto. Clique nele para editar | Enter salva"
+	}
+	a.annoDirty = true
+}
+
+// repeatingKey imita a repetição de tecla do sistema.
+func repeatingKey(key ebiten.Key) bool {
+	d := inpututil.KeyPressDuration(key)
+	return d == 1 || (d >= 30 && d%3 == 0)
+}
+
+func (a *App) drawTextCaret(screen *ebiten.Image) {
+	if !a.editing {
+		return
+	}
+	an := a.annotations[a.editIndex]
+	box, dot, face := textLayout(an)
+	if face == nil {
+		return
+	}
+	x := dot.X.Ceil() + font.MeasureString(face, an.Text).Ceil() + 1
+	m := face.Metrics()
+	y0 := (dot.Y - m.Ascent).Floor()
+	h := (m.Ascent + m.Descent).Ceil()
+	ebitenutil.DrawRect(screen, float64(x), float64(y0), 2, float64(h), an.Color)
+	drawRectBorder(screen, box.Min.X-2, box.Min.Y-2, box.Max.X+2, box.Max.Y+2)
+}
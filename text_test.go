@@ -0,0 +1,126 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func countColor(img *image.RGBA, r image.Rectangle, match func(color.RGBA) bool) int {
+	n := 0
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if match(img.RGBAAt(x, y)) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func TestTextLayoutGrowsWithTextAndSize(t *testing.T) {
+	short := annotation{Tool: toolText, Points: []image.Point{{10, 10}}, Text: "oi", Size: 16}
+	long := short
+	long.Text = "olá, mundo"
+	big := short
+	big.Size = 32
+
+	bs, _, face := textLayout(short)
+	if face == nil {
+		t.Fatalf("fonte embutida não carregou")
+	}
+	bl, _, _ := textLayout(long)
+	bb, _, _ := textLayout(big)
+	if bs.Min != image.Pt(10, 10) {
+		t.Fatalf("caixa deve começar na âncora: %v", bs)
+	}
+	if bl.Dx() <= bs.Dx() || bl.Dy() != bs.Dy() {
+		t.Fatalf("texto maior: %v vs %v", bl, bs)
+	}
+	if bb.Dy() <= bs.Dy() {
+		t.Fatalf("fonte maior: %v vs %v", bb, bs)
+	}
+}
+
+func TestDrawTextWithPill(t *testing.T) {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	an := annotation{Tool: toolText, Points: []image.Point{{5, 5}}, Text: "Bug aqui", Size: 20, Color: white, Pill: true}
+	img := blankCanvas(200, 60)
+	drawAnnotations(img, []annotation{an}, image.Point{})
+
+	box, _, _ := textLayout(an)
+	dark := countColor(img, box, func(c color.RGBA) bool { return c.R < 120 })
+	light := countColor(img, box, func(c color.RGBA) bool { return c == white })
+	if dark < box.Dx()*box.Dy()/2 {
+		t.Fatalf("fundo escuro não desenhado (%d px)", dark)
+	}
+	if light == 0 {
+		t.Fatalf("texto não desenhado")
+	}
+	outside := image.Rect(box.Max.X+2, 0, 200, 60)
+	if n := countColor(img, outside, func(c color.RGBA) bool { return c != white }); n != 0 {
+		t.Fatalf("%d pixels alterados fora da caixa", n)
+	}
+}
+
+func TestDrawTextWithoutPill(t *testing.T) {
+	an := annotation{Tool: toolText, Points: []image.Point{{5, 5}}, Text: "x", Size: 24, Color: annoRed}
+	img := blankCanvas(60, 60)
+	drawAnnotations(img, []annotation{an}, image.Point{})
+	box, _, _ := textLayout(an)
+	red := countColor(img, box, func(c color.RGBA) bool { return c == annoRed })
+	white := countColor(img, box, func(c color.RGBA) bool { return c.G == 255 })
+	if red == 0 || white == 0 {
+		t.Fatalf("esperava texto vermelho sobre fundo original: red=%d white=%d", red, white)
+	}
+}
+
+func TestTextEditingLifecycle(t *testing.T) {
+	app := &App{rawBG: blankCanvas(200, 100)}
+	app.selX0, app.selY0, app.selX1, app.selY1 = 0, 0, 200, 100
+	app.hasSelection = true
+	app.selectTool(toolText)
+
+	app.placeText(image.Pt(10, 10))
+	if !app.editing || len(app.annotations) != 1 {
+		t.Fatalf("caixa não criada")
+	}
+	// caixa vazia é descartada ao terminar
+	app.finishTextEdit()
+	if app.editing || len(app.annotations) != 0 {
+		t.Fatalf("caixa vazia deveria sumir: %+v", app.annotations)
+	}
+
+	app.placeText(image.Pt(10, 10))
+	app.annotations[app.editIndex].Text = "abc"
+	// clicar em outro ponto fecha a caixa atual e abre outra
+	app.placeText(image.Pt(100, 60))
+	if len(app.annotations) != 2 || app.editIndex != 1 {
+		t.Fatalf("esperava duas caixas, got %d (edit=%d)", len(app.annotations), app.editIndex)
+	}
+	app.finishTextEdit()
+	if len(app.annotations) != 1 {
+		t.Fatalf("segunda caixa vazia deveria sumir")
+	}
+
+	// clicar sobre um texto existente reabre a edição
+	app.placeText(image.Pt(12, 14))
+	if !app.editing || app.editIndex != 0 || len(app.annotations) != 1 {
+		t.Fatalf("texto existente não reaberto: edit=%v idx=%d n=%d", app.editing, app.editIndex, len(app.annotations))
+	}
+	if app.annotations[0].Size != defaultTextSize || !app.annotations[0].Pill {
+		t.Fatalf("padrões de texto: %+v", app.annotations[0])
+	}
+}
+
+func TestSelectionImageIncludesText(t *testing.T) {
+	app := &App{rawBG: blankCanvas(120, 80)}
+	app.selX0, app.selY0, app.selX1, app.selY1 = 20, 20, 120, 80
+	app.hasSelection = true
+	app.annotations = []annotation{{Tool: toolText, Points: []image.Point{{25, 25}}, Text: "OK", Size: 20, Color: annoRed}}
+
+	out := app.selectionImage()
+	if n := countColor(out, out.Bounds(), func(c color.RGBA) bool { return c == annoRed }); n == 0 {
+		t.Fatalf("texto ausente na exportação")
+	}
+}
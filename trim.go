@@ -0,0 +1,81 @@
+package main
+
+import (
+	"image"
+	"image/color"
+)
+
+// trimTolerance é a diferença máxima por canal para duas cores contarem
+// como iguais (compressão e dithering do papel de parede).
+const trimTolerance = 8
+
+// trimUniform encolhe r para dentro enquanto a linha ou coluna da borda for
+// uniforme na cor de fundo daquele lado (a cor do pixel de canto onde o
+// lado começa, com tolerância tol por canal). Cada lado é testado de novo
+// após os outros encolherem, até nada mudar. Se r inteiro for fundo, não há
+// conteúdo para enquadrar e r volta intacto.
+func trimUniform(img *image.RGBA, r image.Rectangle, tol int) image.Rectangle {
+	r = r.Intersect(img.Bounds())
+	if r.Empty() {
+		return r
+	}
+	top, left := img.RGBAAt(r.Min.X, r.Min.Y), img.RGBAAt(r.Min.X, r.Min.Y)
+	bottom, right := img.RGBAAt(r.Min.X, r.Max.Y-1), img.RGBAAt(r.Max.X-1, r.Min.Y)
+	t := r
+	for changed := true; changed && !t.Empty(); {
+		changed = false
+		for !t.Empty() && uniformLine(img, t.Min.X, t.Min.Y, 1, 0, t.Dx(), top, tol) {
+			t.Min.Y++
+			changed = true
+		}
+		for !t.Empty() && uniformLine(img, t.Min.X, t.Max.Y-1, 1, 0, t.Dx(), bottom, tol) {
+			t.Max.Y--
+			changed = true
+		}
+		for !t.Empty() && uniformLine(img, t.Min.X, t.Min.Y, 0, 1, t.Dy(), left, tol) {
+			t.Min.X++
+			changed = true
+		}
+		for !t.Empty() && uniformLine(img, t.Max.X-1, t.Min.Y, 0, 1, t.Dy(), right, tol) {
+			t.Max.X--
+			changed = true
+		}
+	}
+	if t.Empty() {
+		return r
+	}
+	return t
+}
+
+// uniformLine testa se os n pixels a partir de (x, y), no passo (dx, dy),
+// estão a até tol de ref.
+func uniformLine(img *image.RGBA, x, y, dx, dy, n int, ref color.RGBA, tol int) bool {
+	for i := 0; i < n; i++ {
+		c := img.RGBAAt(x+i*dx, y+i*dy)
+		if abs(int(c.R)-int(ref.R)) > tol || abs(int(c.G)-int(ref.G)) > tol || abs(int(c.B)-int(ref.B)) > tol {
+			return false
+		}
+	}
+	return true
+}
+
+// trimSelection (B) aplica o recorte inteligente à seleção travada.
+func (a *App) trimSelection() {
+	if !a.hasSelection {
+		return
+	}
+	from := a.selectionRect()
+	to := trimUniform(a.rawBG, from, trimTolerance)
+	if to == from {
+		a.infoMessage = "Nenhuma borda uniforme para recortar."
+		return
+	}
+	if to.Dx() < 2 || to.Dy() < 2 {
+		a.infoMessage = "Recorte deixaria a seleção pequena demais."
+		return
+	}
+	a.finishTextEdit()
+	a.setSelection(to)
+	a.record(selectionCmd{from: from, to: to})
+	a.infoMessage = "Bordas recortadas: " + selectionLabel(to, image.Point{}, false)
+}
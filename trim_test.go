@@ -0,0 +1,67 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// framedWindow: papel de parede liso com um leve ruído e uma "janela"
+// xadrez no meio (nenhuma linha ou coluna dela é uniforme).
+func framedWindow() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 120, 80))
+	for y := 0; y < 80; y++ {
+		for x := 0; x < 120; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(60 + (x*7+y*3)%5), G: 60, B: 90, A: 255})
+		}
+	}
+	for y := 20; y < 55; y++ {
+		for x := 30; x < 90; x++ {
+			c := color.RGBA{R: 250, G: 250, B: 250, A: 255}
+			if (x+y)%2 == 0 {
+				c = color.RGBA{R: 10, G: 10, B: 10, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestTrimUniform(t *testing.T) {
+	img := framedWindow()
+	rectEq(t, trimUniform(img, img.Bounds(), trimTolerance), image.Rect(30, 20, 90, 55))
+
+	// já justo: nada muda
+	r := image.Rect(30, 20, 90, 55)
+	rectEq(t, trimUniform(img, r, trimTolerance), r)
+
+	// respeita o retângulo pedido
+	rectEq(t, trimUniform(img, image.Rect(0, 0, 60, 40), trimTolerance), image.Rect(30, 20, 60, 40))
+}
+
+func TestTrimUniformTolerance(t *testing.T) {
+	img := blankCanvas(40, 40)
+	fill(img, image.Rect(10, 10, 30, 30), color.RGBA{R: 200, A: 255})
+	img.SetRGBA(0, 0, color.RGBA{R: 250, G: 250, B: 250, A: 255}) // ruído de compressão
+	rectEq(t, trimUniform(img, img.Bounds(), trimTolerance), image.Rect(10, 10, 30, 30))
+	// sem tolerância, o pixel ruidoso segura a borda superior e a esquerda
+	got := trimUniform(img, img.Bounds(), 0)
+	if got.Min != (image.Point{}) {
+		t.Fatalf("tol=0 deveria manter o ruído: %v", got)
+	}
+}
+
+func TestTrimUniformAllUniform(t *testing.T) {
+	img := blankCanvas(20, 20)
+	r := image.Rect(2, 2, 18, 18)
+	rectEq(t, trimUniform(img, r, trimTolerance), r)
+}
+
+func TestTrimSelectionUndo(t *testing.T) {
+	app := &App{rawBG: framedWindow()}
+	app.setSelection(image.Rect(5, 5, 110, 70))
+	app.trimSelection()
+	rectEq(t, app.selectionRect(), image.Rect(30, 20, 90, 55))
+	app.undo()
+	rectEq(t, app.selectionRect(), image.Rect(5, 5, 110, 70))
+}
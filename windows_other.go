@@ -0,0 +1,13 @@
+//go:build !linux && !freebsd && !netbsd && !openbsd
+
+package main
+
+func platformWindowLister() WindowLister {
+	return unavailableWindowLister{}
+}
+
+type unavailableWindowLister struct{}
+
+func (unavailableWindowLister) Windows() ([]windowInfo, error) {
+	return nil, errWindowsUnavailable
+}
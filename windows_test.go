@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"image"
+	"testing"
+)
+
+type fakeWindowLister struct {
+	windows []windowInfo
+	err     error
+}
+
+func (f fakeWindowLister) Windows() ([]windowInfo, error) { return f.windows, f.err }
+
+func TestWindowAtPrefersTopmost(t *testing.T) {
+	windows := []windowInfo{
+		{ID: 1, Title: "fundo", Frame: image.Rect(0, 0, 100, 100)},
+		{ID: 2, Title: "topo", Frame: image.Rect(50, 50, 150, 150)},
+	}
+	if i := windowAt(windows, image.Pt(60, 60)); i != 1 {
+		t.Fatalf("sobreposição deveria achar a do topo: %d", i)
+	}
+	if i := windowAt(windows, image.Pt(10, 10)); i != 0 {
+		t.Fatalf("esperava a do fundo: %d", i)
+	}
+	if i := windowAt(windows, image.Pt(200, 200)); i != -1 {
+		t.Fatalf("fora de qualquer janela: %d", i)
+	}
+}
+
+func TestPickWindowSelectsFrame(t *testing.T) {
+	// display 1 começa em x=-40 no desktop virtual; a janela vaza para fora
+	app := &App{
+		rawBG:        blankCanvas(60, 40),
+		displays:     []image.Rectangle{image.Rect(0, 0, 60, 40), image.Rect(-40, 0, 0, 30)},
+		windowLister: fakeWindowLister{},
+		windows: []windowInfo{
+			{ID: 7, Title: "Terminal", Frame: image.Rect(10, 5, 50, 35)},
+		},
+	}
+	app.startWindowPick()
+	if !app.picking {
+		t.Fatalf("deveria entrar no modo de escolha: %q", app.infoMessage)
+	}
+	if app.pickWindowAt(1, 1) {
+		t.Fatalf("clique fora de janela não seleciona")
+	}
+	if !app.pickWindowAt(20, 20) || app.picking {
+		t.Fatalf("clique na janela deveria selecionar e sair do modo")
+	}
+	rectEq(t, app.selectionRect(), image.Rect(10, 5, 50, 35))
+	app.undo()
+	if app.hasSelection {
+		t.Fatalf("desfazer deveria remover a seleção da janela")
+	}
+
+	app.curDisp = 1 // mesma janela vista do display à esquerda: fica de fora
+	app.startWindowPick()
+	if app.pickWindowAt(20, 20) {
+		t.Fatalf("janela fora do display não deveria ser escolhida")
+	}
+
+	app.modeAll = true // no desktop virtual o x da janela desloca 40px
+	app.rawBG = blankCanvas(100, 40)
+	if !app.pickWindowAt(60, 20) {
+		t.Fatalf("modo todos monitores: janela não encontrada")
+	}
+	rectEq(t, app.selectionRect(), image.Rect(50, 5, 90, 35))
+}
+
+func TestStartWindowPickReportsErrors(t *testing.T) {
+	app := &App{rawBG: blankCanvas(60, 40), windowLister: fakeWindowLister{}, windowsErr: errors.New("sem X")}
+	app.startWindowPick()
+	if app.picking || app.infoMessage == "" {
+		t.Fatalf("erro do backend deveria virar mensagem: %q", app.infoMessage)
+	}
+	app.windowsErr = nil
+	app.startWindowPick()
+	if app.picking {
+		t.Fatalf("sem janelas não entra no modo de escolha")
+	}
+}
+
+// A lista de janelas vem da captura: janelas abertas ou movidas depois não
+// podem ser escolhidas sobre uma imagem em que não aparecem.
+func TestWindowPickUsesCaptureSnapshot(t *testing.T) {
+	desktop, displays := fakeDesktop()
+	terminal := windowInfo{ID: 7, Title: "Terminal", Frame: image.Rect(10, 5, 50, 35)}
+	app := &App{
+		capturer:     newFakeCapturer(desktop, displays...),
+		displays:     displays,
+		curDisp:      1,
+		rawBG:        blankCanvas(60, 40),
+		windowLister: fakeWindowLister{windows: []windowInfo{terminal}},
+	}
+	app.startCapture()
+	waitCapture(t, app)
+
+	app.windowLister = fakeWindowLister{windows: []windowInfo{{ID: 9, Title: "Nova", Frame: image.Rect(0, 0, 60, 40)}}}
+	app.startWindowPick()
+	if !app.picking {
+		t.Fatalf("deveria entrar no modo de escolha: %q", app.infoMessage)
+	}
+	w, _, ok := app.hoveredWindow(20, 20)
+	if !ok || w.ID != terminal.ID {
+		t.Fatalf("deveria usar as janelas da captura, veio %+v", w)
+	}
+}
@@ -0,0 +1,141 @@
+//go:build linux || freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"os"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+func platformWindowLister() WindowLister {
+	return x11WindowLister{}
+}
+
+// x11WindowLister lê a lista de janelas mantida pelo gerenciador de janelas
+// (EWMH). display vazio usa $DISPLAY.
+type x11WindowLister struct {
+	display string
+}
+
+func (l x11WindowLister) Windows() ([]windowInfo, error) {
+	conn, err := xgb.NewConnDisplay(l.display)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+
+	// a lista empilhada dá a ordem certa para achar a janela sob o mouse;
+	// _NET_CLIENT_LIST (ordem de mapeamento) fica como alternativa
+	var ids []uint32
+	for _, name := range []string{"_NET_CLIENT_LIST_STACKING", "_NET_CLIENT_LIST"} {
+		if ids, err = x11CardinalList(conn, root, name, xproto.AtomWindow); err == nil && len(ids) > 0 {
+			break
+		}
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("o gerenciador de janelas não publica _NET_CLIENT_LIST")
+	}
+
+	pid := uint32(os.Getpid())
+	var windows []windowInfo
+	for _, id := range ids {
+		win := xproto.Window(id)
+		if p, err := x11CardinalList(conn, win, "_NET_WM_PID", xproto.AtomCardinal); err == nil && len(p) == 1 && p[0] == pid {
+			continue // a própria janela do app cobre a tela inteira
+		}
+		frame, ok := x11Frame(conn, root, win)
+		if !ok {
+			continue
+		}
+		windows = append(windows, windowInfo{ID: id, Title: x11Title(conn, win), Frame: frame})
+	}
+	return windows, nil
+}
+
+// x11Frame devolve o quadro de win em coordenadas da raiz: a geometria do
+// ancestral filho direto da raiz (a moldura de gerenciadores que
+// reparentam), ou a da própria janela somada a _NET_FRAME_EXTENTS.
+// Janelas não visíveis (minimizadas, em outra área de trabalho) ficam de
+// fora.
+func x11Frame(conn *xgb.Conn, root, win xproto.Window) (image.Rectangle, bool) {
+	top := win
+	for {
+		tree, err := xproto.QueryTree(conn, top).Reply()
+		if err != nil {
+			return image.Rectangle{}, false
+		}
+		if tree.Parent == root || tree.Parent == 0 {
+			break
+		}
+		top = tree.Parent
+	}
+	attrs, err := xproto.GetWindowAttributes(conn, top).Reply()
+	if err != nil || attrs.MapState != xproto.MapStateViewable {
+		return image.Rectangle{}, false
+	}
+	geom, err := xproto.GetGeometry(conn, xproto.Drawable(top)).Reply()
+	if err != nil {
+		return image.Rectangle{}, false
+	}
+	b := int(geom.BorderWidth)
+	x, y := int(geom.X), int(geom.Y)
+	r := image.Rect(x, y, x+int(geom.Width)+2*b, y+int(geom.Height)+2*b)
+	if top == win {
+		if ext, err := x11CardinalList(conn, win, "_NET_FRAME_EXTENTS", xproto.AtomCardinal); err == nil && len(ext) == 4 {
+			r.Min.X -= int(ext[0])
+			r.Max.X += int(ext[1])
+			r.Min.Y -= int(ext[2])
+			r.Max.Y += int(ext[3])
+		}
+	}
+	return r, !r.Empty()
+}
+
+// x11Title lê _NET_WM_NAME (UTF-8) ou, na falta dele, WM_NAME.
+func x11Title(conn *xgb.Conn, win xproto.Window) string {
+	if utf8Atom, err := internAtom(conn, "UTF8_STRING"); err == nil {
+		if s, err := x11StringProp(conn, win, "_NET_WM_NAME", utf8Atom); err == nil && s != "" {
+			return s
+		}
+	}
+	s, _ := x11StringProp(conn, win, "WM_NAME", xproto.AtomString)
+	return s
+}
+
+func x11StringProp(conn *xgb.Conn, win xproto.Window, name string, typ xproto.Atom) (string, error) {
+	atom, err := internAtom(conn, name)
+	if err != nil {
+		return "", err
+	}
+	reply, err := xproto.GetProperty(conn, false, win, atom, typ, 0, 1024).Reply()
+	if err != nil {
+		return "", err
+	}
+	return string(reply.Value), nil
+}
+
+// x11CardinalList lê uma propriedade de formato 32 (CARDINAL, WINDOW...).
+func x11CardinalList(conn *xgb.Conn, win xproto.Window, name string, typ xproto.Atom) ([]uint32, error) {
+	atom, err := internAtom(conn, name)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := xproto.GetProperty(conn, false, win, atom, typ, 0, 1<<16).Reply()
+	if err != nil {
+		return nil, err
+	}
+	if reply.Format != 32 {
+		return nil, nil
+	}
+	out := make([]uint32, 0, len(reply.Value)/4)
+	for i := 0; i+4 <= len(reply.Value); i += 4 {
+		out = append(out, binary.LittleEndian.Uint32(reply.Value[i:]))
+	}
+	return out, nil
+}
@@ -0,0 +1,141 @@
+//go:build linux || freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"image"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+// startXvfb sobe um servidor X virtual só para o teste (pulado sem Xvfb).
+// -displayfd deixa o próprio Xvfb escolher um número de display livre.
+func startXvfb(t *testing.T) string {
+	t.Helper()
+	bin, err := exec.LookPath("Xvfb")
+	if err != nil {
+		t.Skip("Xvfb não encontrado")
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	cmd := exec.Command(bin, "-displayfd", "3", "-screen", "0", "640x480x24", "-nolisten", "tcp")
+	cmd.ExtraFiles = []*os.File{w}
+	err = cmd.Start()
+	w.Close()
+	if err != nil {
+		t.Skipf("Xvfb não iniciou: %v", err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Signal(os.Interrupt)
+		cmd.Wait()
+	})
+
+	num := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(r).ReadString('\n')
+		num <- strings.TrimSpace(line)
+	}()
+	select {
+	case n := <-num:
+		if n == "" {
+			t.Skip("Xvfb não informou o display")
+		}
+		return ":" + n
+	case <-time.After(10 * time.Second):
+		t.Skip("Xvfb não respondeu")
+	}
+	return ""
+}
+
+// dummyWindow cria e mapeia uma janela de topo com título opcional.
+func dummyWindow(t *testing.T, conn *xgb.Conn, r image.Rectangle, title string, pid uint32) xproto.Window {
+	t.Helper()
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+	wid, err := xproto.NewWindowId(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = xproto.CreateWindowChecked(conn, 0, wid, root, int16(r.Min.X), int16(r.Min.Y),
+		uint16(r.Dx()), uint16(r.Dy()), 0, xproto.WindowClassInputOutput, 0, 0, nil).Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title != "" {
+		utf8Atom, _ := internAtom(conn, "UTF8_STRING")
+		nameAtom, _ := internAtom(conn, "_NET_WM_NAME")
+		xproto.ChangeProperty(conn, xproto.PropModeReplace, wid, nameAtom, utf8Atom, 8, uint32(len(title)), []byte(title))
+	}
+	if pid != 0 {
+		pidAtom, _ := internAtom(conn, "_NET_WM_PID")
+		xproto.ChangeProperty(conn, xproto.PropModeReplace, wid, pidAtom, xproto.AtomCardinal, 32, 1, cardinals(pid))
+	}
+	return wid
+}
+
+func cardinals(values ...uint32) []byte {
+	b := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(b[4*i:], v)
+	}
+	return b
+}
+
+func TestX11WindowListerXvfb(t *testing.T) {
+	display := startXvfb(t)
+	conn, err := xgb.NewConnDisplay(display)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+
+	editor := dummyWindow(t, conn, image.Rect(10, 20, 210, 120), "Editor — ação", 0)
+	term := dummyWindow(t, conn, image.Rect(100, 80, 250, 230), "Terminal", 0)
+	hidden := dummyWindow(t, conn, image.Rect(0, 0, 50, 50), "Minimizada", 0)
+	self := dummyWindow(t, conn, image.Rect(0, 0, 640, 480), "go-screentake", uint32(os.Getpid()))
+	for _, w := range []xproto.Window{editor, term, self} {
+		xproto.MapWindow(conn, w)
+	}
+
+	// sem gerenciador de janelas, o teste publica a lista ele mesmo
+	list, _ := internAtom(conn, "_NET_CLIENT_LIST")
+	xproto.ChangeProperty(conn, xproto.PropModeReplace, root, list, xproto.AtomWindow, 32, 4,
+		cardinals(uint32(editor), uint32(term), uint32(hidden), uint32(self)))
+	if _, err := xproto.GetInputFocus(conn).Reply(); err != nil { // sincroniza
+		t.Fatal(err)
+	}
+
+	windows, err := x11WindowLister{display: display}.Windows()
+	if err != nil {
+		t.Fatalf("Windows: %v", err)
+	}
+	if len(windows) != 2 {
+		t.Fatalf("esperava 2 janelas visíveis (sem a minimizada e sem o app), got %+v", windows)
+	}
+	if windows[0].Title != "Editor — ação" || windows[1].Title != "Terminal" {
+		t.Fatalf("títulos: %q, %q", windows[0].Title, windows[1].Title)
+	}
+	rectEq(t, windows[0].Frame, image.Rect(10, 20, 210, 120))
+	rectEq(t, windows[1].Frame, image.Rect(100, 80, 250, 230))
+	if i := windowAt(windows, image.Pt(150, 100)); windows[i].ID != uint32(term) {
+		t.Fatalf("sobreposição deveria achar o terminal (último da lista)")
+	}
+}
+
+func TestX11WindowListerWithoutEWMH(t *testing.T) {
+	display := startXvfb(t)
+	if _, err := (x11WindowLister{display: display}).Windows(); err == nil {
+		t.Fatalf("sem _NET_CLIENT_LIST deveria falhar")
+	}
+}